@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"github.com/bhollier/ui/pkg/ui"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/render/software"
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
+	"image/png"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime/pprof"
 )
 
@@ -16,6 +21,9 @@ func main() {
 	cpuProfile := flag.String("cpu-profile", "", "Where to output the CPU profile")
 	width := flag.Float64("window-width", 800, "The window's width")
 	height := flag.Float64("window-height", 600, "The window's height")
+	screenshot := flag.String("screenshot", "",
+		"Where to output a PNG of the design (without opening a window)")
+	watch := flag.Bool("watch", false, "Reload the design when its files change")
 
 	// Parse them
 	flag.Parse()
@@ -31,7 +39,37 @@ func main() {
 	path := flag.Arg(0)
 
 	// Open the ui assets folder
-	uiDir := http.Dir("./assets/ui")
+	uiPath := "./assets/ui"
+	uiDir := http.Dir(uiPath)
+
+	// If a screenshot was asked for
+	if *screenshot != "" {
+		// Load the design
+		root, err := element.NewRoot(uiDir, nil, path, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Render it without a window
+		img, err := software.Render(root.Element, pixel.R(0, 0, *width, *height))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Create the file
+		file, err := os.Create(*screenshot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+
+		// Write the image to it
+		err = png.Encode(file, img)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	var design *ui.Design
 
@@ -60,18 +98,45 @@ func main() {
 			pixelgl.WindowConfig{
 				Bounds: pixel.R(0, 0, *width, *height),
 				Title:  path,
-			})
+			}, nil)
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		// Register the callback the
+		// test design's buttons use
+		design.RegisterCallback("testcallback", func(ctx *ui.CallbackContext) error {
+			log.Printf("Pressed at %v", ctx.Position)
+			return nil
+		})
+
 		// Initialise the design
 		err = design.Init()
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		// Start then wait for it to finish
-		design.StartThenWait(true)
+		// If the design should be reloaded
+		// when its files change
+		if *watch {
+			err = design.Watch(uiPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		// Log the design's errors
+		go func() {
+			for err := range design.Errors() {
+				log.Printf("Error: %+v", err)
+			}
+		}()
+
+		// Start it (stopping it if the program
+		// is interrupted) then wait for it to finish
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		design.StartContext(ctx)
+		design.Wait()
 	})
 }
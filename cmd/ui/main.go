@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Type for a subcommand, which is given
+// its arguments and returns the exit code
+type command func(args []string) int
+
+// The subcommands
+var commands = map[string]command{
+	"schema":   schema,
+	"validate": validate,
+}
+
+// Function to print the usage
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: ui <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  schema    write the XML schema of the element types")
+	fmt.Fprintln(os.Stderr, "  validate  check design files for errors")
+}
+
+func main() {
+	// Get the subcommand
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown command '%s'\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	// Run it
+	os.Exit(cmd(os.Args[2:]))
+}
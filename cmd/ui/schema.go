@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"io"
+	"os"
+)
+
+// The namespace of the builtin element types
+const builtinNamespace = "http://github.com/bhollier/ui/api/schema"
+
+// Function to write the XML schema
+// of the registered element types
+func schema(args []string) int {
+	// Define the flags
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	namespace := flags.String("namespace", builtinNamespace,
+		"The namespace of the element types to write the schema for")
+	output := flags.String("o", "", "The file to write the schema to (instead of stdout)")
+	list := flags.Bool("list", false, "List the namespaces of the element types instead")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ui schema [flags]")
+		flags.PrintDefaults()
+	}
+
+	// Parse them
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Error: unexpected arguments")
+		flags.Usage()
+		return 2
+	}
+
+	registry := element.DefaultRegistry()
+	if *list {
+		for _, ns := range registry.Namespaces() {
+			fmt.Println(ns)
+		}
+		return 0
+	}
+
+	// Get where to write the schema
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	// Write it
+	err = registry.WriteSchema(w, *namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	_ "github.com/bhollier/ui/pkg/ui/builtin"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Type for a problem found in a design
+type diagnostic struct {
+	// The file the problem is in
+	File string `json:"file"`
+	// Where in the file the problem is
+	// (0 if it isn't known)
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// What the problem is
+	Message string `json:"message"`
+}
+
+// Function to convert the diagnostic to a
+// string (e.g. "main.xml:3:5: message")
+func (d diagnostic) String() string {
+	pos := d.File
+	if d.Line > 0 {
+		pos = element.SourcePos{Path: d.File, Line: d.Line, Column: d.Column}.String()
+	}
+	return pos + ": " + d.Message
+}
+
+// Function to convert an error from
+// validating the design at the given
+// path into diagnostics. Unknown callbacks
+// are only problems if checkCallbacks is
+// true (otherwise it isn't known which
+// callbacks the program registers)
+func diagnostics(path string, err error, checkCallbacks bool) []diagnostic {
+	// If there are multiple problems
+	var loadErrs element.LoadErrors
+	if errors.As(err, &loadErrs) {
+		diags := make([]diagnostic, 0, len(loadErrs.Errors))
+		for _, err := range loadErrs.Errors {
+			diags = append(diags, diagnostics(path, err, checkCallbacks)...)
+		}
+		return diags
+	}
+
+	// If it's an unknown callback
+	var callbackErr element.UnknownCallbackError
+	if !checkCallbacks && errors.As(err, &callbackErr) {
+		return nil
+	}
+
+	// If it's known where the problem is
+	var loadErr element.LoadError
+	if errors.As(err, &loadErr) && loadErr.Pos.Path != "" {
+		return []diagnostic{{
+			File:    loadErr.Pos.Path,
+			Line:    loadErr.Pos.Line,
+			Column:  loadErr.Pos.Column,
+			Message: loadErr.Err.Error(),
+		}}
+	}
+	return []diagnostic{{File: path, Message: err.Error()}}
+}
+
+// Function to validate design files
+func validate(args []string) int {
+	// Define the flags
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	root := flags.String("root", ".",
+		"The directory the design paths (and their imports) are relative to")
+	jsonOutput := flags.Bool("json", false, "Output the problems as a JSON array")
+	strict := flags.Bool("strict", false, "Require element names to have a namespace")
+	callbacks := flags.String("callbacks", "",
+		"A comma-separated list of the callbacks the program registers. "+
+			"If given, using any other callback is a problem")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ui validate [flags] <design.xml>...")
+		flags.PrintDefaults()
+	}
+
+	// Parse them
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no paths given")
+		flags.Usage()
+		return 2
+	}
+
+	// Only check the callbacks
+	// if they were given
+	checkCallbacks := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "callbacks" {
+			checkCallbacks = true
+		}
+	})
+
+	// Create the registry
+	registry := element.NewRegistry(element.DefaultRegistry())
+	registry.SetStrict(*strict)
+	// With the program's callbacks
+	for _, name := range strings.Split(*callbacks, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			registry.RegisterCallbackWithArgs(name,
+				func(*element.CallbackContext, ...interface{}) error { return nil })
+		}
+	}
+
+	// Validate each design
+	fs := http.Dir(*root)
+	diags := make([]diagnostic, 0)
+	for _, path := range flags.Args() {
+		err = element.ValidateDesign(fs, path, registry)
+		if err != nil {
+			diags = append(diags, diagnostics(path, err, checkCallbacks)...)
+		}
+	}
+
+	// Output the problems
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(diags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+			return 2
+		}
+	} else {
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+	}
+
+	if len(diags) > 0 {
+		return 1
+	}
+	return 0
+}
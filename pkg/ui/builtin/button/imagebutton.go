@@ -2,10 +2,8 @@ package button
 
 import (
 	"encoding/xml"
-	"errors"
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -42,21 +40,18 @@ func (e *ImageButton) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err
 		return err
 	}
 
-	// If no image was given
+	return d.Skip()
+}
+
+// Function to check the element
+// for problems
+func (e *ImageButton) Validate() error {
+	// If no image was given, it
+	// can't match the content
 	if e.ImageImpl.GetField() == "" {
-		// If it wants to match the content
-		if e.GetRelWidth().MatchContent {
-			return errors.New("invalid width attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		} else if e.GetRelHeight().MatchContent {
-			return errors.New("invalid height attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		}
+		return element.CheckNoContent(e)
 	}
-
-	return d.Skip()
+	return nil
 }
 
 // Function to reset the element
@@ -72,42 +67,39 @@ func (e *ImageButton) IsInitialised() bool {
 		e.ImageImpl.IsInitialised()
 }
 
-// Function to initialise the element
-func (e *ImageButton) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
-	// Initialise the button
-	err := e.ButtonImpl.Init(window, bounds)
+// Function to measure the element
+func (e *ImageButton) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the button
+	err := e.ButtonImpl.Measure(window, bounds)
 	if err != nil {
 		return err
 	}
 
-	// Initialise the button's image
-	err = element.InitImage(e, &e.ImageImpl)
+	// Measure the button's image
+	err = element.MeasureImage(e, &e.ImageImpl)
 	if err != nil {
 		return err
 	}
 
-	// If no image was given
-	if e.ImageImpl.GetField() == "" {
-		// If it wants to match the content
-		if e.GetRelWidth().MatchContent {
-			return errors.New("invalid width attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		} else if e.GetRelHeight().MatchContent {
-			return errors.New("invalid height attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		}
+	return e.Validate()
+}
+
+// Function to initialise the element
+func (e *ImageButton) Init(r element.Renderer) error {
+	// Initialise the button
+	err := e.ButtonImpl.Init(r)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// Initialise the button's image
+	return element.InitImage(e, &e.ImageImpl)
 }
 
-// Function that is called when there
-// is a new event
-func (e *ImageButton) NewEvent(window *pixelgl.Window) {
-	// Call the button's new event
-	element.ButtonNewEvent(e, window)
+// Function to handle an event
+func (e *ImageButton) HandleEvent(ev element.Event) {
+	// Call the button's event handler
+	element.ButtonHandleEvent(e, ev)
 }
 
 // Function to draw the element
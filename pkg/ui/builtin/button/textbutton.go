@@ -2,10 +2,8 @@ package button
 
 import (
 	"encoding/xml"
-	"errors"
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -26,6 +24,66 @@ func NewTextButton(fs http.FileSystem, name xml.Name, parent element.Layout) ele
 // The XML name of the element
 var TextButtonTypeName = xml.Name{Space: "http://github.com/bhollier/ui/api/schema", Local: "TextButton"}
 
+// Function to set the text content
+func (e *TextButton) SetText(s string) error {
+	// Set the text
+	err := e.TextImpl.SetText(s)
+	if err != nil {
+		return err
+	}
+
+	// If the size depends on the content
+	if e.GetRelWidth().MatchContent ||
+		e.GetRelHeight().MatchContent {
+		// The element needs laying out again
+		e.InvalidateLayout()
+	} else {
+		// Otherwise it just needs drawing again
+		e.InvalidatePaint()
+	}
+	return nil
+}
+
+// Type for the state of a text button
+// that's kept when the design is reloaded
+type textButtonState struct {
+	// The button's state
+	button interface{}
+	// The text set with SetText
+	// (or nil, if it wasn't)
+	text *string
+}
+
+// Function to get the button's state and
+// the text set with SetText, so they're
+// kept when the design is reloaded
+func (e *TextButton) SaveState() interface{} {
+	state := textButtonState{button: e.ButtonImpl.SaveState()}
+	if e.GetText() != e.GetField() {
+		text := e.GetText()
+		state.text = &text
+	}
+	if state.button == nil && state.text == nil {
+		return nil
+	}
+	return state
+}
+
+// Function to set the button's state and
+// text to another text button's (see SaveState)
+func (e *TextButton) RestoreState(state interface{}) {
+	button, ok := state.(textButtonState)
+	if !ok {
+		return
+	}
+	if button.button != nil {
+		e.ButtonImpl.RestoreState(button.button)
+	}
+	if button.text != nil {
+		e.SetText(*button.text)
+	}
+}
+
 // Function to unmarshal an XML element into
 // an element. This function is usually only
 // called by xml.Unmarshal
@@ -42,21 +100,18 @@ func (e *TextButton) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err e
 		return err
 	}
 
-	// If no text was given
+	return d.Skip()
+}
+
+// Function to check the element
+// for problems
+func (e *TextButton) Validate() error {
+	// If no text was given, it
+	// can't match the content
 	if e.TextImpl.GetField() == "" {
-		// If it wants to match the content
-		if e.GetRelWidth().MatchContent {
-			return errors.New("invalid width attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		} else if e.GetRelHeight().MatchContent {
-			return errors.New("invalid height attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		}
+		return element.CheckNoContent(e)
 	}
-
-	return d.Skip()
+	return nil
 }
 
 // Function to reset the element
@@ -72,42 +127,27 @@ func (e *TextButton) IsInitialised() bool {
 		e.TextImpl.IsInitialised()
 }
 
-// Function to initialise the element
-func (e *TextButton) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
-	// Initialise the button
-	err := e.ButtonImpl.Init(window, bounds)
+// Function to measure the element
+func (e *TextButton) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the button
+	err := e.ButtonImpl.Measure(window, bounds)
 	if err != nil {
 		return err
 	}
 
-	// Initialise the button's text
-	err = element.InitText(e, &e.TextImpl)
+	// Measure the button's text
+	err = element.MeasureText(e, &e.TextImpl)
 	if err != nil {
 		return err
 	}
 
-	// If no text was given
-	if e.TextImpl.GetField() == "" {
-		// If it wants to match the content
-		if e.GetRelWidth().MatchContent {
-			return errors.New("invalid width attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		} else if e.GetRelHeight().MatchContent {
-			return errors.New("invalid height attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		}
-	}
-
-	return nil
+	return e.Validate()
 }
 
-// Function that is called when there
-// is a new event
-func (e *TextButton) NewEvent(window *pixelgl.Window) {
-	// Call the button's new event
-	element.ButtonNewEvent(e, window)
+// Function to handle an event
+func (e *TextButton) HandleEvent(ev element.Event) {
+	// Call the button's event handler
+	element.ButtonHandleEvent(e, ev)
 }
 
 // Function to draw the element
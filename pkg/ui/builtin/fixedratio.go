@@ -6,7 +6,6 @@ import (
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -30,6 +29,10 @@ func NewFixedRatio(fs http.FileSystem, name xml.Name, parent element.Layout) ele
 // The XML name of the import element
 var FixedRatioTypeName = xml.Name{Space: "http://github.com/bhollier/ui/api/schema", Local: "FixedRatio"}
 
+// Function to get the most children
+// the element can have in XML
+func (e *FixedRatio) MaxChildren() int { return 1 }
+
 // Function to unmarshal an XML element into
 // an element. This function is usually only
 // called by xml.Unmarshal
@@ -54,13 +57,13 @@ func (e *FixedRatio) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err e
 
 	// If there are no children
 	if len(e.LayoutImpl.Children) == 0 {
-		return errors.New("no children on XML element '" +
-			element.FullName(e, ".", true) + "'")
+		return element.ReportLoadError(errors.New("no children on XML element '"+
+			element.FullName(e, ".", true)+"'"), e)
 
 		// If there are multiple
 	} else if len(e.LayoutImpl.Children) > 1 {
-		return errors.New("multiple children on XML element '" +
-			element.FullName(e, ".", true) + "'")
+		return element.ReportLoadError(errors.New("multiple children on XML element '"+
+			element.FullName(e, ".", true)+"'"), e)
 	}
 
 	return nil
@@ -79,6 +82,13 @@ func (e *FixedRatio) Reset() {
 	e.LayoutImpl.Reset()
 }
 
+// Function to determine whether
+// the element is arranged
+func (e *FixedRatio) IsArranged() bool {
+	return e.Impl.IsArranged() &&
+		element.ChildrenAreArranged(e)
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *FixedRatio) IsInitialised() bool {
@@ -86,15 +96,45 @@ func (e *FixedRatio) IsInitialised() bool {
 		element.ChildrenAreInitialised(e)
 }
 
-// Function to initialise an element's
-// position, width and height. Because
-// it doesn't know the element's actual
-// size, it won't set the width or height
-// if the relative width or height is
+// Function to get the properties the
+// child's bounds depend on, which is
+// just the bounds of the fixed ratio
+func (e *FixedRatio) ArrangeDependencies(element.Element) []element.LayoutDependency {
+	return []element.LayoutDependency{{Element: e, Property: element.LayoutBounds}}
+}
+
+// Function to measure the element
+func (e *FixedRatio) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element part of the fixed ratio
+	err := e.Impl.Measure(window, bounds)
+	if err != nil {
+		return err
+	}
+
+	// If the width is meant to match the content size
+	if e.GetRelWidth().MatchContent {
+		// Set the width as the child's
+		e.SetActualWidth(e.GetChild(0).GetActualWidth())
+	}
+
+	// If the height is meant to match the content size
+	if e.GetRelHeight().MatchContent {
+		// Set the height as the child's
+		e.SetActualHeight(e.GetChild(0).GetActualHeight())
+	}
+
+	return nil
+}
+
+// Function to arrange the element
+// and its child. Because it doesn't
+// know the element's actual size, it
+// won't set the width or height if the
+// relative width or height is
 // "match_content"
-func (e *FixedRatio) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error) {
-	// Initialise the element part of the fixed ratio
-	err = e.Impl.Init(window, bounds)
+func (e *FixedRatio) Arrange(window pixel.Rect, bounds *pixel.Rect) (err error) {
+	// Arrange the element part of the fixed ratio
+	err = e.Impl.Arrange(window, bounds)
 	if err != nil {
 		return err
 	}
@@ -130,14 +170,14 @@ func (e *FixedRatio) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error
 				Max: min.Add(dimensions),
 			}
 
-			// Initialise the child with the bounds
-			err = e.GetChild(0).Init(window, &childBounds)
+			// Arrange the child with the bounds
+			err = element.MeasureAndArrange(e.GetChild(0), window, &childBounds)
 			if err != nil {
 				return err
 			}
 		} else {
-			// Initialise the child with the bounds
-			err = e.GetChild(0).Init(window, nil)
+			// Arrange the child with the bounds
+			err = element.MeasureAndArrange(e.GetChild(0), window, nil)
 			if err != nil {
 				return err
 			}
@@ -145,39 +185,30 @@ func (e *FixedRatio) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error
 
 		// Otherwise
 	} else {
-		// Initialise the child with no bounds
-		err = e.GetChild(0).Init(window, nil)
+		// Arrange the child with no bounds
+		err = element.MeasureAndArrange(e.GetChild(0), window, nil)
 		if err != nil {
 			return err
 		}
 	}
 
-	// If the width is meant to match the content size
-	if e.GetRelWidth().MatchContent {
-		// Set the width as the child's
-		e.SetActualWidth(e.GetChild(0).GetActualWidth())
-	}
-
-	// If the height is meant to match the content size
-	if e.GetRelHeight().MatchContent {
-		// Set the height as the child's
-		e.SetActualHeight(e.GetChild(0).GetActualHeight())
-	}
-
 	return nil
 }
 
-// Function that is called when there
-// is a new event
-func (e *FixedRatio) NewEvent(window *pixelgl.Window) {
-	e.Impl.NewEvent(window)
-	e.LayoutImpl.NewEvent(window)
+// Function to initialise the
+// element and its child
+func (e *FixedRatio) Init(r element.Renderer) error {
+	// Initialise the element part of the fixed ratio
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
+	}
+	// Initialise the child
+	return element.InitChildren(e, r)
 }
 
 // Function to draw the element
 func (e *FixedRatio) Draw() {
-	// Draw the element
-	e.Impl.Draw()
-	// Draw the layout
+	// Draw the layout (and its background)
 	element.DrawLayout(e)
 }
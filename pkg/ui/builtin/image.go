@@ -2,10 +2,8 @@ package builtin
 
 import (
 	"encoding/xml"
-	"errors"
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -59,39 +57,50 @@ func (e *Image) IsInitialised() bool {
 		e.ImageImpl.IsInitialised()
 }
 
-// Function to initialise the element
-// (load textures, create sprites, set
-// sprite locations, etc.)
-func (e *Image) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
-	// Initialise the element
-	err := e.Impl.Init(window, bounds)
+// Function to measure the element
+// (load textures, set the size of the
+// element if it matches the image, etc.)
+func (e *Image) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element
+	err := e.Impl.Measure(window, bounds)
 	if err != nil {
 		return err
 	}
 
-	// Initialise the image
-	err = element.InitImage(e, &e.ImageImpl)
+	// Measure the image
+	err = element.MeasureImage(e, &e.ImageImpl)
 	if err != nil {
 		return err
 	}
 
-	// If no image was given
+	return e.Validate()
+}
+
+// Function to check the element
+// for problems
+func (e *Image) Validate() error {
+	// If no image was given, it
+	// can't match the content
 	if e.ImageImpl.GetField() == "" {
-		// If it wants to match the content
-		if e.GetRelWidth().MatchContent {
-			return errors.New("invalid width attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		} else if e.GetRelHeight().MatchContent {
-			return errors.New("invalid height attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		}
+		return element.CheckNoContent(e)
 	}
-
 	return nil
 }
 
+// Function to initialise the element
+// (create its canvas, create sprites,
+// etc.)
+func (e *Image) Init(r element.Renderer) error {
+	// Initialise the element
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
+	}
+
+	// Initialise the image
+	return element.InitImage(e, &e.ImageImpl)
+}
+
 // Function to draw the element
 func (e *Image) Draw() {
 	// Draw the element
@@ -4,7 +4,6 @@ import (
 	"encoding/xml"
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -27,6 +26,11 @@ func NewImport(fs http.FileSystem, name xml.Name, parent element.Layout) element
 // The XML name of the import element
 var ImportTypeName = xml.Name{Space: "http://github.com/bhollier/ui/api/schema", Local: "Import"}
 
+// Function to get the most children the
+// element can have in XML (none, as its
+// child comes from the imported design)
+func (e *Import) MaxChildren() int { return 0 }
+
 // Function to unmarshal an XML element into
 // an element. This function is usually only
 // called by xml.Unmarshal
@@ -44,14 +48,17 @@ func (e *Import) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error
 	}
 
 	// Create the root
-	root, err := element.NewRoot(e.GetFS(), e, e.Path)
+	root, err := element.NewRoot(e.GetFS(), e, e.Path, e.GetRegistry())
 	if err != nil {
-		return err
+		err = element.ReportLoadError(err, e)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Set it as the only child
+		e.Children = []element.Element{root.Element}
 	}
 
-	// Set it as the only child
-	e.Children = []element.Element{root.Element}
-
 	return d.Skip()
 }
 
@@ -68,6 +75,13 @@ func (e *Import) Reset() {
 	e.LayoutImpl.Reset()
 }
 
+// Function to determine whether
+// the element is arranged
+func (e *Import) IsArranged() bool {
+	return e.Impl.IsArranged() &&
+		element.ChildrenAreArranged(e)
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *Import) IsInitialised() bool {
@@ -75,21 +89,17 @@ func (e *Import) IsInitialised() bool {
 		element.ChildrenAreInitialised(e)
 }
 
-// Function to initialise an element's
-// position, width and height. Because
-// it doesn't know the element's actual
-// size, it won't set the width or height
-// if the relative width or height is
-// "match_content"
-func (e *Import) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error) {
-	// Initialise the element part of the import
-	err = e.Impl.Init(window, bounds)
-	if err != nil {
-		return err
-	}
+// Function to get the properties the
+// child's bounds depend on, which is
+// just the bounds of the import
+func (e *Import) ArrangeDependencies(element.Element) []element.LayoutDependency {
+	return []element.LayoutDependency{{Element: e, Property: element.LayoutBounds}}
+}
 
-	// Initialise the child
-	err = e.GetChild(0).Init(window, bounds)
+// Function to measure the element
+func (e *Import) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element part of the import
+	err := e.Impl.Measure(window, bounds)
 	if err != nil {
 		return err
 	}
@@ -109,17 +119,42 @@ func (e *Import) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error) {
 	return nil
 }
 
-// Function that is called when there
-// is a new event
-func (e *Import) NewEvent(window *pixelgl.Window) {
-	e.Impl.NewEvent(window)
-	e.LayoutImpl.NewEvent(window)
+// Function to arrange the element
+// and its child. Because it doesn't
+// know the element's actual size, it
+// won't set the width or height if the
+// relative width or height is
+// "match_content"
+func (e *Import) Arrange(window pixel.Rect, bounds *pixel.Rect) (err error) {
+	// Arrange the element part of the import
+	err = e.Impl.Arrange(window, bounds)
+	if err != nil {
+		return err
+	}
+
+	// Arrange the child
+	err = element.MeasureAndArrange(e.GetChild(0), window, bounds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Function to initialise the
+// element and its child
+func (e *Import) Init(r element.Renderer) error {
+	// Initialise the element part of the import
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
+	}
+	// Initialise the child
+	return element.InitChildren(e, r)
 }
 
 // Function to draw the element
 func (e *Import) Draw() {
-	// Draw the element
-	e.Impl.Draw()
-	// Draw the layout
+	// Draw the layout (and its background)
 	element.DrawLayout(e)
 }
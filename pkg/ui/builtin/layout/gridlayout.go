@@ -5,7 +5,6 @@ import (
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"math"
 	"net/http"
 )
@@ -140,6 +139,13 @@ func (e *GridLayout) Reset() {
 	e.LayoutImpl.Reset()
 }
 
+// Function to determine whether
+// the element is arranged
+func (e *GridLayout) IsArranged() bool {
+	return e.Impl.IsArranged() &&
+		element.ChildrenAreArranged(e)
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *GridLayout) IsInitialised() bool {
@@ -147,96 +153,181 @@ func (e *GridLayout) IsInitialised() bool {
 		element.ChildrenAreInitialised(e)
 }
 
-// Function to initialise the element
-func (e *GridLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
-	// Initialise the element part of the layout
-	err := e.Impl.Init(window, bounds)
-	if err != nil {
-		return err
+// Function to get the element the cell
+// width or height is relative to, which
+// is the layout itself, unless the
+// layout matches its content (as its
+// size then depends on the cells), in
+// which case it's the parent (or nil,
+// for the window)
+func (e *GridLayout) cellParent(prop element.LayoutProperty) element.Element {
+	rel := e.GetRelWidth()
+	if prop == element.LayoutHeight {
+		rel = e.GetRelHeight()
 	}
+	if !rel.MatchContent {
+		return e
+	}
+	return e.GetParent()
+}
 
-	// The actual width of a cell
-	actualCellWidth := new(float64)
+// Function to get the properties the
+// cell width or height depends on
+func (e *GridLayout) cellDependencies(prop element.LayoutProperty) []element.LayoutDependency {
+	cell := e.CellWidth
+	if prop == element.LayoutHeight {
+		cell = e.CellHeight
+	}
+	parent := e.cellParent(prop)
+	if parent == nil || !(cell.MatchParent || cell.Unit == util.Percent) {
+		return nil
+	}
+	return []element.LayoutDependency{{Element: parent, Property: prop}}
+}
 
+// Function to get the properties the
+// layout's width or height depend on,
+// which include the cell width or height
+// if the layout matches its content
+func (e *GridLayout) SizeDependencies(prop element.LayoutProperty) []element.LayoutDependency {
+	if e.cellParent(prop) == element.Element(e) {
+		return nil
+	}
+	return e.cellDependencies(prop)
+}
+
+// Function to get the properties the
+// given child's bounds depend on, which
+// are the layout's size and bounds, the
+// size of every child and whatever the
+// cell size depends on (as they decide
+// the size of the cells)
+func (e *GridLayout) ArrangeDependencies(element.Element) []element.LayoutDependency {
+	deps := []element.LayoutDependency{
+		{Element: e, Property: element.LayoutWidth},
+		{Element: e, Property: element.LayoutHeight},
+		{Element: e, Property: element.LayoutBounds},
+	}
+	for _, child := range e.Children {
+		deps = append(deps,
+			element.LayoutDependency{Element: child, Property: element.LayoutWidth},
+			element.LayoutDependency{Element: child, Property: element.LayoutHeight})
+	}
+	deps = append(deps, e.cellDependencies(element.LayoutWidth)...)
+	deps = append(deps, e.cellDependencies(element.LayoutHeight)...)
+	return deps
+}
+
+// Function to calculate the size of a
+// cell, which is the largest of the
+// minimum cell size and the size of
+// each child. The width and/or height
+// is nil if it isn't known yet
+func (e *GridLayout) cellSize(window pixel.Rect) (width, height *float64) {
 	// If the cell width is to match the content
 	if e.CellWidth.MatchContent {
 		// Just set the minimum to 0
-		*actualCellWidth = 0
+		width = new(float64)
 	} else {
 		// Otherwise calculate the minimum from the relative width
-		// (with the layout itself as the parent)
 		// todo cell width can't be match_bounds
-		actualCellWidth = element.CalculateWidth(e, window, nil, e.CellWidth)
+		width = element.CalculateWidth(
+			e.cellParent(element.LayoutWidth), window, nil, e.CellWidth)
 	}
 
-	// If the actual cell width is still known
-	if actualCellWidth != nil {
+	// If the cell width is still known
+	if width != nil {
 		// Iterate over the children
 		for _, child := range e.Children {
 			// If the child's width isn't known
 			if child.GetActualWidth() == nil {
 				// Reset the width
-				actualCellWidth = nil
+				width = nil
 				break
 			}
 			// Get the max width
-			*actualCellWidth = math.Max(*actualCellWidth, *child.GetActualWidth())
-		}
-
-		// If the width is meant to match the content size
-		// and the cell width was calculated
-		if e.GetRelWidth().MatchContent && actualCellWidth != nil {
-			// Set the actual width as the column
-			// width multiplied by the number of columns
-			actualWidth := *actualCellWidth * float64(len(e.grid[0]))
-			e.SetActualWidth(&actualWidth)
+			*width = math.Max(*width, *child.GetActualWidth())
 		}
 	}
 
-	// The actual height of a cell
-	actualCellHeight := new(float64)
-
 	// If the cell height is to match the content
-	if e.CellWidth.MatchContent {
+	if e.CellHeight.MatchContent {
 		// Just set the minimum to 0
-		*actualCellHeight = 0
+		height = new(float64)
 	} else {
 		// Otherwise calculate the minimum from the relative height
-		// (with the layout itself as the parent)
-		// todo cell width can't be match_bounds
-		actualCellHeight = element.CalculateHeight(e, window, nil, e.CellHeight)
+		// todo cell height can't be match_bounds
+		height = element.CalculateHeight(
+			e.cellParent(element.LayoutHeight), window, nil, e.CellHeight)
 	}
 
-	// If the actual cell height is still known
-	if actualCellHeight != nil {
+	// If the cell height is still known
+	if height != nil {
 		// Iterate over the children
 		for _, child := range e.Children {
 			// If the child's height isn't known
 			if child.GetActualHeight() == nil {
 				// Reset the height
-				actualCellHeight = nil
+				height = nil
 				break
 			}
 			// Get the max height
-			*actualCellHeight = math.Max(*actualCellHeight, *child.GetActualHeight())
+			*height = math.Max(*height, *child.GetActualHeight())
 		}
+	}
+	return
+}
 
-		// If the height is meant to match the content size
-		// and the cell height was calculated
-		if e.GetRelHeight().MatchContent && actualCellHeight != nil {
-			// Set the actual width as the row
-			// height multiplied by the number of rows
-			actualHeight := *actualCellHeight * float64(len(e.grid))
-			e.SetActualHeight(&actualHeight)
-		}
+// Function to measure the element
+func (e *GridLayout) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element part of the layout
+	err := e.Impl.Measure(window, bounds)
+	if err != nil {
+		return err
+	}
+
+	// Calculate the size of a cell
+	actualCellWidth, actualCellHeight := e.cellSize(window)
+
+	// If the width is meant to match the content size
+	// and the cell width was calculated
+	if e.GetRelWidth().MatchContent && actualCellWidth != nil {
+		// Set the actual width as the column
+		// width multiplied by the number of columns
+		actualWidth := *actualCellWidth * float64(len(e.grid[0]))
+		e.SetActualWidth(&actualWidth)
+	}
+
+	// If the height is meant to match the content size
+	// and the cell height was calculated
+	if e.GetRelHeight().MatchContent && actualCellHeight != nil {
+		// Set the actual width as the row
+		// height multiplied by the number of rows
+		actualHeight := *actualCellHeight * float64(len(e.grid))
+		e.SetActualHeight(&actualHeight)
+	}
+
+	return nil
+}
+
+// Function to arrange the element
+// and its children
+func (e *GridLayout) Arrange(window pixel.Rect, bounds *pixel.Rect) error {
+	// Arrange the element part of the layout
+	err := e.Impl.Arrange(window, bounds)
+	if err != nil {
+		return err
 	}
 
+	// Calculate the size of a cell
+	actualCellWidth, actualCellHeight := e.cellSize(window)
+
 	// Iterate over the rows of the grid
 	for y, ys := range e.grid {
 		// Iterate over the children of the row
 		for x, child := range ys {
-			// If the child hasn't been initialised yet
-			if !child.IsInitialised() {
+			// If the child hasn't been arranged yet
+			if !child.IsArranged() {
 				childBounds := (*pixel.Rect)(nil)
 				// If the layout's minimum,
 				// cell width and height are known
@@ -264,8 +355,8 @@ func (e *GridLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 					childBounds = nil
 				}
 
-				// Initialise the child
-				err := child.Init(window, childBounds)
+				// Arrange the child
+				err := element.MeasureAndArrange(child, window, childBounds)
 				if err != nil {
 					return err
 				}
@@ -276,17 +367,20 @@ func (e *GridLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 	return nil
 }
 
-// Function that is called when there
-// is a new event
-func (e *GridLayout) NewEvent(window *pixelgl.Window) {
-	e.Impl.NewEvent(window)
-	e.LayoutImpl.NewEvent(window)
+// Function to initialise the
+// element and its children
+func (e *GridLayout) Init(r element.Renderer) error {
+	// Initialise the element part of the layout
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
+	}
+	// Initialise the children
+	return element.InitChildren(e, r)
 }
 
 // Function to draw the element
 func (e *GridLayout) Draw() {
-	// Draw the element
-	e.Impl.Draw()
-	// Draw the layout
+	// Draw the layout (and its background)
 	element.DrawLayout(e)
 }
@@ -5,7 +5,6 @@ import (
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"math"
 	"net/http"
 )
@@ -71,6 +70,13 @@ func (e *LinearLayout) Reset() {
 	e.LayoutImpl.Reset()
 }
 
+// Function to determine whether
+// the element is arranged
+func (e *LinearLayout) IsArranged() bool {
+	return e.Impl.IsArranged() &&
+		element.ChildrenAreArranged(e)
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *LinearLayout) IsInitialised() bool {
@@ -78,8 +84,30 @@ func (e *LinearLayout) IsInitialised() bool {
 		element.ChildrenAreInitialised(e)
 }
 
-// Function to initialise the element
-func (e *LinearLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
+// Function to get the properties the
+// given child's bounds depend on, which
+// are the layout's size and bounds and
+// the size of the child and every
+// child before it
+func (e *LinearLayout) ArrangeDependencies(child element.Element) []element.LayoutDependency {
+	deps := []element.LayoutDependency{
+		{Element: e, Property: element.LayoutWidth},
+		{Element: e, Property: element.LayoutHeight},
+		{Element: e, Property: element.LayoutBounds},
+	}
+	for i := 0; i < e.NumChildren(); i++ {
+		deps = append(deps,
+			element.LayoutDependency{Element: e.GetChild(i), Property: element.LayoutWidth},
+			element.LayoutDependency{Element: e.GetChild(i), Property: element.LayoutHeight})
+		if e.GetChild(i) == child {
+			break
+		}
+	}
+	return deps
+}
+
+// Function to measure the element
+func (e *LinearLayout) Measure(window pixel.Rect, bounds *pixel.Rect) error {
 	// If the layout's width isn't known and
 	// the width is meant to match the content size
 	if e.GetActualWidth() == nil && e.GetRelWidth().MatchContent {
@@ -176,8 +204,15 @@ func (e *LinearLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 		}
 	}
 
-	// Initialise the element part of the layout
-	err := e.Impl.Init(window, bounds)
+	// Measure the element part of the layout
+	return e.Impl.Measure(window, bounds)
+}
+
+// Function to arrange the element
+// and its children
+func (e *LinearLayout) Arrange(window pixel.Rect, bounds *pixel.Rect) error {
+	// Arrange the element part of the layout
+	err := e.Impl.Arrange(window, bounds)
 	if err != nil {
 		return err
 	}
@@ -203,42 +238,42 @@ func (e *LinearLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 		childPos = nil
 	}
 
-	// Initialise the children
+	// Arrange the children
 	var child element.Element
 	for i := 0; i < e.NumChildren(); i++ {
 		child = e.GetChild(i)
 
-		// If the child hasn't been initialised yet
-		if !child.IsInitialised() {
-			// The child's bounds (default is nil)
-			var childBounds *pixel.Rect
-			// If the child's position can be calculated
-			// (if the previous position is known and the
-			// child's width/height is known)
-			if childPos != nil &&
-				child.GetActualWidth() != nil &&
-				child.GetActualHeight() != nil {
-				// Calculate the child's size
-				childSize := pixel.V(*child.GetActualWidth(),
-					*child.GetActualHeight())
-				// Minus the Y by the size of the child
-				childPos.Y -= childSize.Y
-				// Set the child bounds
-				childBounds = &pixel.Rect{
-					Min: *childPos,
-					Max: childPos.Add(childSize),
-				}
+		// The child's bounds (default is nil)
+		var childBounds *pixel.Rect
+		// If the child's position can be calculated
+		// (if the previous position is known and the
+		// child's width/height is known)
+		if childPos != nil &&
+			child.GetActualWidth() != nil &&
+			child.GetActualHeight() != nil {
+			// Calculate the child's size
+			childSize := pixel.V(*child.GetActualWidth(),
+				*child.GetActualHeight())
+			// Minus the Y by the size of the child
+			childPos.Y -= childSize.Y
+			// Set the child bounds
+			childBounds = &pixel.Rect{
+				Min: *childPos,
+				Max: childPos.Add(childSize),
+			}
 
-				// Increase childPos (for the next child)
-				if e.Orientation == util.HorizontalOrientation {
-					childPos.X += childSize.X
-				}
-			} else {
-				childPos = nil
+			// Increase childPos (for the next child)
+			if e.Orientation == util.HorizontalOrientation {
+				childPos.X += childSize.X
 			}
+		} else {
+			childPos = nil
+		}
 
-			// Initialise the child
-			err := child.Init(window, childBounds)
+		// If the child hasn't been arranged yet
+		if !child.IsArranged() {
+			// Arrange the child
+			err := element.MeasureAndArrange(child, window, childBounds)
 			if err != nil {
 				return err
 			}
@@ -248,17 +283,20 @@ func (e *LinearLayout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 	return nil
 }
 
-// Function that is called when there
-// is a new event
-func (e *LinearLayout) NewEvent(window *pixelgl.Window) {
-	e.Impl.NewEvent(window)
-	e.LayoutImpl.NewEvent(window)
+// Function to initialise the
+// element and its children
+func (e *LinearLayout) Init(r element.Renderer) error {
+	// Initialise the element part of the layout
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
+	}
+	// Initialise the children
+	return element.InitChildren(e, r)
 }
 
 // Function to draw the element
 func (e *LinearLayout) Draw() {
-	// Draw the element
-	e.Impl.Draw()
-	// Draw the layout
+	// Draw the layout (and its background)
 	element.DrawLayout(e)
 }
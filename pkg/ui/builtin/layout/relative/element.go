@@ -42,17 +42,25 @@ func newRelativeElement(fs http.FileSystem, parent *Layout) relativeElement {
 	return relativeElement{parent: parent, fs: fs}
 }
 
+// Function to get the element
+// the relative element wraps
+func (e *relativeElement) UnwrapElement() element.Element { return e.Element }
+
 // Function to unmarshal an XML element into
 // a relative element. This function is
 // only called by xml.Unmarshal
 func (e *relativeElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
 	// Create an element of the type
-	e.Element = element.New(e.fs, start.Name, e.parent)
-	// If the element wasn't created
-	if e.Element == nil {
-		return errors.New("unknown element type '" +
-			element.XMLNameToString(start.Name) + "'")
+	e.Element, err = element.New(e.fs, start.Name, e.parent)
+	if err != nil {
+		err = element.ReportChildLoadError(err, e.parent, d)
+		if err != nil {
+			return element.WrapChildLoadError(err, e.parent, d)
+		}
+		// Skip the element
+		return d.Skip()
 	}
+	element.RecordSourcePos(e.Element, d)
 
 	// Create an array of the relative
 	// attributes
@@ -95,8 +103,11 @@ func (e *relativeElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (
 	// If none of the attributes are set
 	if e.TopOf == zeroRelativePosition && e.BottomOf == zeroRelativePosition &&
 		e.LeftOf == zeroRelativePosition && e.RightOf == zeroRelativePosition {
-		return errors.New("XML element '" + element.FullName(e, ".", false) +
-			"' has no position attribute, must have at least 'top-of', 'bottom-of', 'left-of' or 'right-of'")
+		err = element.ReportLoadError(errors.New("XML element '"+element.FullName(e, ".", false)+
+			"' has no position attribute, must have at least 'top-of', 'bottom-of', 'left-of' or 'right-of'"), e)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Create an array of the element's
@@ -16,6 +16,10 @@ func init() {
 			}
 			return reflect.ValueOf(val), nil
 		})
+	// "parent", a relative quantity or an element's ID
+	element.RegisterAttrSchema(
+		reflect.TypeOf((*relativePosition)(nil)).Elem(), element.AttrSchema{
+			Name: "relative-position", Pattern: `\S+`})
 
 	// Register the relative element types
 	element.Register(LayoutTypeName,
@@ -6,8 +6,8 @@ import (
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
+	"reflect"
 )
 
 // Layout type for displaying elements
@@ -30,6 +30,13 @@ func NewLayout(fs http.FileSystem, name xml.Name, parent element.Layout) element
 // The XML name of the element
 var LayoutTypeName = xml.Name{Space: "http://github.com/bhollier/ui/api/schema", Local: "RelativeLayout"}
 
+// Function to get the type of the
+// attributes the layout's children
+// have (e.g. "top-of")
+func (e *Layout) ChildAttrs() reflect.Type {
+	return reflect.TypeOf((*relativeElement)(nil)).Elem()
+}
+
 // Function to get one of a layout's
 // child elements
 func (e *Layout) GetChild(n int) element.Element { return e.children[n].Element }
@@ -50,6 +57,50 @@ func (e *Layout) GetChildByID(id string) element.Element {
 	return nil
 }
 
+// Function to get the children in the
+// order they're arranged in, where each
+// child comes after the children it's
+// relative to, so the layout only needs
+// arranging once. Children that are
+// relative to each other in a loop (which
+// is a LayoutCycleError anyway) keep
+// their XML order
+func (e *Layout) arrangeOrder() []relativeElement {
+	order := make([]relativeElement, 0, len(e.children))
+	added := make([]bool, len(e.children))
+	visiting := make([]bool, len(e.children))
+
+	// Recursive function to add a child
+	// after the children it's relative to
+	var add func(i int)
+	add = func(i int) {
+		if added[i] || visiting[i] {
+			return
+		}
+		visiting[i] = true
+		child := e.children[i]
+		for _, pos := range []relativePosition{
+			child.TopOf, child.BottomOf, child.LeftOf, child.RightOf} {
+			if pos.ElementID == "" {
+				continue
+			}
+			for j, other := range e.children {
+				if other.GetID() != nil && *other.GetID() == pos.ElementID {
+					add(j)
+					break
+				}
+			}
+		}
+		added[i] = true
+		order = append(order, child)
+	}
+
+	for i := range e.children {
+		add(i)
+	}
+	return order
+}
+
 // Function to unmarshal an XML element into
 // an element. This function is usually only
 // called by xml.Unmarshal
@@ -70,11 +121,14 @@ func (e *Layout) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error
 	// the content, throw an error
 	// todo maybe allow the relativelayout to match content
 	if e.GetRelWidth().MatchContent {
-		return errors.New("invalid width attribute value 'match_content' on XML element '" +
-			element.FullName(e, ".", false) + "'")
+		err = element.ReportLoadError(errors.New("invalid width attribute value 'match_content' on XML element '"+
+			element.FullName(e, ".", false)+"'"), e)
 	} else if e.GetRelHeight().MatchContent {
-		return errors.New("invalid height attribute value 'match_content' on XML element '" +
-			element.FullName(e, ".", false) + "'")
+		err = element.ReportLoadError(errors.New("invalid height attribute value 'match_content' on XML element '"+
+			element.FullName(e, ".", false)+"'"), e)
+	}
+	if err != nil {
+		return err
 	}
 
 	// Create the array of children
@@ -95,10 +149,13 @@ Loop:
 			// Decode the XML element into it
 			err = d.DecodeElement(&elem, &tt)
 			if err != nil {
-				return err
+				return element.WrapLoadError(err, elem.Element)
 			}
 			// Add it to the children array
-			e.children = append(e.children, elem)
+			// (unless it was skipped)
+			if elem.Element != nil {
+				e.children = append(e.children, elem)
+			}
 
 			// If this is the end of the element
 		case xml.EndElement:
@@ -113,26 +170,39 @@ Loop:
 		// If the top of element exists but the ID leads nowhere
 		if child.TopOf != zeroRelativePosition && child.TopOf.ElementID != "" &&
 			e.GetChildByID(child.TopOf.ElementID) == nil {
-			return element.NewNoElemError(child.Element, child.TopOf.ElementID, "top-of")
+			err = element.ReportLoadError(element.NewNoElemError(
+				child.Element, child.TopOf.ElementID, "top-of"), child.Element)
+			if err != nil {
+				return element.WrapLoadError(err, child.Element)
+			}
 		}
 		// If the bottom of element exists but the ID leads nowhere
 		if child.BottomOf != zeroRelativePosition && child.BottomOf.ElementID != "" &&
 			e.GetChildByID(child.BottomOf.ElementID) == nil {
-			return element.NewNoElemError(child.Element, child.BottomOf.ElementID, "bottom-of")
+			err = element.ReportLoadError(element.NewNoElemError(
+				child.Element, child.BottomOf.ElementID, "bottom-of"), child.Element)
+			if err != nil {
+				return element.WrapLoadError(err, child.Element)
+			}
 		}
 		// If the left of element exists but the ID leads nowhere
 		if child.LeftOf != zeroRelativePosition && child.LeftOf.ElementID != "" &&
 			e.GetChildByID(child.LeftOf.ElementID) == nil {
-			return element.NewNoElemError(child.Element, child.LeftOf.ElementID, "left-of")
+			err = element.ReportLoadError(element.NewNoElemError(
+				child.Element, child.LeftOf.ElementID, "left-of"), child.Element)
+			if err != nil {
+				return element.WrapLoadError(err, child.Element)
+			}
 		}
 		// If the right of element exists but the ID leads nowhere
 		if child.RightOf != zeroRelativePosition && child.RightOf.ElementID != "" &&
 			e.GetChildByID(child.RightOf.ElementID) == nil {
-			return element.NewNoElemError(child.Element, child.RightOf.ElementID, "right-of")
+			err = element.ReportLoadError(element.NewNoElemError(
+				child.Element, child.RightOf.ElementID, "right-of"), child.Element)
+			if err != nil {
+				return element.WrapLoadError(err, child.Element)
+			}
 		}
-
-		// todo  make sure there are no circular
-		// todo  dependencies
 	}
 
 	return nil
@@ -156,6 +226,13 @@ func (e *Layout) Reset() {
 	}
 }
 
+// Function to determine whether
+// the element is arranged
+func (e *Layout) IsArranged() bool {
+	return e.Impl.IsArranged() &&
+		element.ChildrenAreArranged(e)
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *Layout) IsInitialised() bool {
@@ -163,16 +240,56 @@ func (e *Layout) IsInitialised() bool {
 		element.ChildrenAreInitialised(e)
 }
 
-// Function to initialise the element
-func (e *Layout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
-	// Initialise the element part of the layout
-	err := e.Impl.Init(window, bounds)
+// Function to get the properties the
+// given child's bounds depend on, which
+// are the layout's bounds, the child's
+// size (unless it matches the bounds)
+// and the size and bounds of any
+// element it's relative to
+func (e *Layout) ArrangeDependencies(child element.Element) []element.LayoutDependency {
+	deps := []element.LayoutDependency{{Element: e, Property: element.LayoutBounds}}
+	if !child.GetRelWidth().MatchBounds {
+		deps = append(deps, element.LayoutDependency{Element: child, Property: element.LayoutWidth})
+	}
+	if !child.GetRelHeight().MatchBounds {
+		deps = append(deps, element.LayoutDependency{Element: child, Property: element.LayoutHeight})
+	}
+
+	// Find the relative element
+	for _, relChild := range e.children {
+		if relChild.Element != child {
+			continue
+		}
+		// Iterate over its relative positions
+		for _, pos := range []relativePosition{
+			relChild.TopOf, relChild.BottomOf, relChild.LeftOf, relChild.RightOf} {
+			// If the position is relative to an element
+			if pos.ElementID != "" {
+				relativeElem := e.GetChildByID(pos.ElementID)
+				if relativeElem != nil {
+					deps = append(deps,
+						element.LayoutDependency{Element: relativeElem, Property: element.LayoutWidth},
+						element.LayoutDependency{Element: relativeElem, Property: element.LayoutHeight},
+						element.LayoutDependency{Element: relativeElem, Property: element.LayoutBounds})
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// Function to arrange the element
+// and its children
+func (e *Layout) Arrange(window pixel.Rect, bounds *pixel.Rect) error {
+	// Arrange the element part of the layout
+	err := e.Impl.Arrange(window, bounds)
 	if err != nil {
 		return err
 	}
 
-	// Iterate over the elements
-	for _, child := range e.children {
+	// Iterate over the elements, after
+	// the elements they're relative to
+	for _, child := range e.arrangeOrder() {
 		// Create the child's bounds
 		var childBounds *pixel.Rect
 		// If the bounds are known, then the
@@ -442,8 +559,8 @@ func (e *Layout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 			}
 		}
 
-		// Initialise the child
-		err := child.Init(window, childBounds)
+		// Arrange the child
+		err := element.MeasureAndArrange(child.Element, window, childBounds)
 		if err != nil {
 			return err
 		}
@@ -452,20 +569,20 @@ func (e *Layout) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 	return nil
 }
 
-// Function that is called when there
-// is a new event. This function only
-// calls NewEvent on the child elements
-func (e *Layout) NewEvent(window *pixelgl.Window) {
-	e.Impl.NewEvent(window)
-	for _, child := range e.children {
-		child.NewEvent(window)
+// Function to initialise the
+// element and its children
+func (e *Layout) Init(r element.Renderer) error {
+	// Initialise the element part of the layout
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
 	}
+	// Initialise the children
+	return element.InitChildren(e, r)
 }
 
 // Function to draw the element
 func (e *Layout) Draw() {
-	// Draw the element
-	e.Impl.Draw()
-	// Draw the layout
+	// Draw the layout (and its background)
 	element.DrawLayout(e)
 }
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
-	"log"
 	"net/http"
 )
 
@@ -34,6 +32,10 @@ func NewScroll(fs http.FileSystem, name xml.Name, parent element.Layout) element
 // The XML name of the import element
 var ScrollTypeName = xml.Name{Space: "http://github.com/bhollier/ui/api/schema", Local: "Scroll"}
 
+// Function to get the most children
+// the element can have in XML
+func (e *Scroll) MaxChildren() int { return 1 }
+
 // Function to unmarshal an XML element into
 // an element. This function is usually only
 // called by xml.Unmarshal
@@ -64,13 +66,13 @@ func (e *Scroll) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error
 
 	// If there are no children
 	if len(e.LayoutImpl.Children) == 0 {
-		return errors.New("no children on XML element '" +
-			element.FullName(e, ".", true) + "'")
+		return element.ReportLoadError(errors.New("no children on XML element '"+
+			element.FullName(e, ".", true)+"'"), e)
 
 		// If there are multiple
 	} else if len(e.LayoutImpl.Children) > 1 {
-		return errors.New("multiple children on XML element '" +
-			element.FullName(e, ".", true) + "'")
+		return element.ReportLoadError(errors.New("multiple children on XML element '"+
+			element.FullName(e, ".", true)+"'"), e)
 	}
 
 	return nil
@@ -91,6 +93,13 @@ func (e *Scroll) Reset() {
 	e.childBounds = nil
 }
 
+// Function to determine whether
+// the element is arranged
+func (e *Scroll) IsArranged() bool {
+	return e.Impl.IsArranged() &&
+		element.ChildrenAreArranged(e)
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *Scroll) IsInitialised() bool {
@@ -98,15 +107,45 @@ func (e *Scroll) IsInitialised() bool {
 		element.ChildrenAreInitialised(e)
 }
 
-// Function to initialise an element's
-// position, width and height. Because
-// it doesn't know the element's actual
-// size, it won't set the width or height
-// if the relative width or height is
+// Function to get the properties the
+// child's bounds depend on, which is
+// just the bounds of the scroll
+func (e *Scroll) ArrangeDependencies(element.Element) []element.LayoutDependency {
+	return []element.LayoutDependency{{Element: e, Property: element.LayoutBounds}}
+}
+
+// Function to measure the element
+func (e *Scroll) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element part of the scroll
+	err := e.Impl.Measure(window, bounds)
+	if err != nil {
+		return err
+	}
+
+	// If the width is meant to match the content size
+	if e.GetRelWidth().MatchContent {
+		// Set the width as the child's
+		e.SetActualWidth(e.GetChild(0).GetActualWidth())
+	}
+
+	// If the height is meant to match the content size
+	if e.GetRelHeight().MatchContent {
+		// Set the height as the child's
+		e.SetActualHeight(e.GetChild(0).GetActualHeight())
+	}
+
+	return nil
+}
+
+// Function to arrange the element
+// and its child. Because it doesn't
+// know the element's actual size, it
+// won't set the width or height if the
+// relative width or height is
 // "match_content"
-func (e *Scroll) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error) {
-	// Initialise the element part of the import
-	err = e.Impl.Init(window, bounds)
+func (e *Scroll) Arrange(window pixel.Rect, bounds *pixel.Rect) (err error) {
+	// Arrange the element part of the scroll
+	err = e.Impl.Arrange(window, bounds)
 	if err != nil {
 		return err
 	}
@@ -117,96 +156,139 @@ func (e *Scroll) Init(window *pixelgl.Window, bounds *pixel.Rect) (err error) {
 		e.childBounds = new(pixel.Rect)
 		*e.parentBounds = *bounds
 		*e.childBounds = *bounds
+
+		// If the scroll itself moved (e.g.
+		// inside another scroll)
+	} else if bounds != nil && *bounds != *e.parentBounds {
+		// Move the child with it
+		*e.childBounds = e.childBounds.Moved(
+			bounds.Min.Sub(e.parentBounds.Min))
+		*e.parentBounds = *bounds
 	}
 
-	// Initialise the child
-	err = e.GetChild(0).Init(window, e.childBounds)
+	// Arrange the child
+	err = element.MeasureAndArrange(e.GetChild(0), window, e.childBounds)
 	if err != nil {
 		return err
 	}
 
-	// If the width is meant to match the content size
-	if e.GetRelWidth().MatchContent {
-		// Set the width as the child's
-		e.SetActualWidth(e.GetChild(0).GetActualWidth())
+	return nil
+}
+
+// Function to initialise the
+// element and its child
+func (e *Scroll) Init(r element.Renderer) error {
+	// Initialise the element part of the scroll
+	err := e.Impl.Init(r)
+	if err != nil {
+		return err
 	}
+	// Initialise the child
+	return element.InitChildren(e, r)
+}
 
-	// If the height is meant to match the content size
-	if e.GetRelHeight().MatchContent {
-		// Set the height as the child's
-		e.SetActualHeight(e.GetChild(0).GetActualHeight())
+// Function to handle an event. The
+// scroll moves its child when the mouse
+// wheel scrolls over it, unless something
+// inside it (like another scroll) already
+// used the event
+func (e *Scroll) HandleEvent(ev element.Event) {
+	// Only handle scroll events that reached
+	// the scroll, and only once it's arranged
+	scrollEv, ok := ev.(*element.ScrollEvent)
+	if !ok || ev.GetPhase() == element.EventCapturePhase ||
+		e.childBounds == nil {
+		return
 	}
 
-	return nil
+	// Move the child by the mouse scroll
+	scroll := scrollEv.Delta.Scaled(float64(e.ScrollRate))
+	if e.moveChild(e.childBounds.Min.X+scroll.X, e.childBounds.Max.Y-scroll.Y) {
+		// The scroll used the event
+		ev.StopPropagation()
+	}
 }
 
-// Function that is called when there
-// is a new event
-func (e *Scroll) NewEvent(window *pixelgl.Window) {
-	e.Impl.NewEvent(window)
-	e.LayoutImpl.NewEvent(window)
-
-	// Get the mouse scroll
-	scroll := window.MouseScroll()
-	// If the scroll wheel moved
-	if scroll != pixel.V(0, 0) {
-		// Copy the current bounds
-		prevBounds := *e.childBounds
-
-		// Only X scroll if the child's width is larger than the bounds
-		if *e.Children[0].GetActualWidth() > e.parentBounds.Size().X {
-			// Move the child's X bounds
-			e.childBounds.Min.X += scroll.X * float64(e.ScrollRate)
-
-			// If the bounds are going too far
-			if e.childBounds.Min.X >= e.parentBounds.Min.X {
-				e.childBounds.Min.X = e.parentBounds.Min.X
-			} else if e.childBounds.Min.X <= e.childBounds.Max.X+
-				*e.Children[0].GetActualWidth() {
-				e.childBounds.Min.X = e.childBounds.Max.X +
-					*e.Children[0].GetActualWidth()
-			}
-		}
+// Function to move the child's left edge
+// and top edge to the given positions, as
+// far as it can be scrolled (and only in
+// the directions the child is larger than
+// the scroll). Returns whether it moved
+func (e *Scroll) moveChild(minX, maxY float64) bool {
+	// Copy the current bounds
+	prevBounds := *e.childBounds
+	width := *e.Children[0].GetActualWidth()
+	height := *e.Children[0].GetActualHeight()
+
+	// Only X scroll if the child's width is larger than the bounds
+	if width > e.parentBounds.Size().X {
+		e.childBounds.Min.X = minX
 
-		// Only Y scroll if the child's height is larger than the bounds
-		if *e.Children[0].GetActualHeight() > e.parentBounds.Size().Y {
-			// Move the child's Y bounds
-			e.childBounds.Max.Y -= scroll.Y * float64(e.ScrollRate)
-
-			// If the bounds are going too far
-			if e.childBounds.Max.Y <= e.parentBounds.Max.Y {
-				e.childBounds.Max.Y = e.parentBounds.Max.Y
-			} else if e.childBounds.Max.Y >= e.childBounds.Min.Y+
-				*e.Children[0].GetActualHeight() {
-				e.childBounds.Max.Y = e.childBounds.Min.Y +
-					*e.Children[0].GetActualHeight()
-			}
+		// If the bounds are going too far
+		if e.childBounds.Min.X >= e.parentBounds.Min.X {
+			e.childBounds.Min.X = e.parentBounds.Min.X
+		} else if e.childBounds.Min.X <= e.childBounds.Max.X-width {
+			e.childBounds.Min.X = e.childBounds.Max.X - width
 		}
+	}
+
+	// Only Y scroll if the child's height is larger than the bounds
+	if height > e.parentBounds.Size().Y {
+		e.childBounds.Max.Y = maxY
 
-		// If the scroll moved
-		if prevBounds != *e.childBounds {
-			// Reset the child element's position
-			e.Children[0].ResetPosition()
-			// While the child is uninitialised
-			for !e.Children[0].IsInitialised() {
-				// Initialise it
-				err := e.Children[0].Init(window, e.childBounds)
-				if err != nil {
-					log.Printf("Error while initialsing XML element '"+
-						element.FullName(e.Children[0], ".", true)+
-						"': %+v", err)
-				}
-			}
-			// Redraw the UI
-			element.DrawUI(e, window)
+		// If the bounds are going too far
+		if e.childBounds.Max.Y <= e.parentBounds.Max.Y {
+			e.childBounds.Max.Y = e.parentBounds.Max.Y
+		} else if e.childBounds.Max.Y >= e.childBounds.Min.Y+height {
+			e.childBounds.Max.Y = e.childBounds.Min.Y + height
 		}
 	}
+
+	// If the scroll moved, the child needs
+	// arranging in its new bounds (by UpdateUI)
+	if prevBounds != *e.childBounds {
+		e.Children[0].InvalidateLayout()
+		return true
+	}
+	return false
+}
+
+// Type for how far a scroll has scrolled
+type scrollState struct {
+	// How far the child's bounds have
+	// moved from the scroll's bounds
+	min, max pixel.Vec
+}
+
+// Function to get how far the
+// scroll has scrolled, so it stays
+// scrolled when the design is reloaded
+func (e *Scroll) SaveState() interface{} {
+	// If it hasn't been arranged or
+	// hasn't scrolled, there's nothing to keep
+	if e.childBounds == nil || *e.childBounds == *e.parentBounds {
+		return nil
+	}
+	return scrollState{
+		min: e.childBounds.Min.Sub(e.parentBounds.Min),
+		max: e.childBounds.Max.Sub(e.parentBounds.Max),
+	}
+}
+
+// Function to scroll the scroll as
+// far as another scroll (see SaveState)
+func (e *Scroll) RestoreState(state interface{}) {
+	scroll, ok := state.(scrollState)
+	if !ok || e.childBounds == nil {
+		return
+	}
+	// Scroll as far as the child (which
+	// may have changed size) can be
+	e.moveChild(e.parentBounds.Min.X+scroll.min.X, e.parentBounds.Max.Y+scroll.max.Y)
 }
 
 // Function to draw the element
 func (e *Scroll) Draw() {
-	// Draw the element
-	e.Impl.Draw()
-	// Draw the layout
+	// Draw the layout (and its background)
 	element.DrawLayout(e)
 }
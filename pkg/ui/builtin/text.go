@@ -2,10 +2,8 @@ package builtin
 
 import (
 	"encoding/xml"
-	"errors"
 	"github.com/bhollier/ui/pkg/ui/element"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -17,12 +15,6 @@ type Text struct {
 
 	// It also has text
 	element.TextImpl
-
-	// The window the element
-	// is in
-	window *pixelgl.Window
-	// The parent bounds
-	parentBounds *pixel.Rect
 }
 
 // Function to create a new text
@@ -41,29 +33,38 @@ func (e *Text) SetText(s string) error {
 		return err
 	}
 
-	// If the width depends on the content
+	// If the size depends on the content
 	if e.GetRelWidth().MatchContent ||
 		e.GetRelHeight().MatchContent {
-		// Reset the element
-		e.Reset()
-		// Update the width and/or height
-		if e.GetRelWidth().MatchContent {
-			newWidth := e.GetSprite().Bounds().Size().X
-			e.SetActualWidth(&newWidth)
-		}
-		if e.GetRelHeight().MatchContent {
-			newHeight := e.GetSprite().Bounds().Size().Y
-			e.SetActualHeight(&newHeight)
-		}
-		// Re-initialise the element
-		err = e.Init(e.window, e.parentBounds)
-		if err != nil {
-			return err
-		}
+		// The element needs laying out again
+		e.InvalidateLayout()
+	} else {
+		// Otherwise it just needs drawing again
+		e.InvalidatePaint()
 	}
 	return nil
 }
 
+// Function to get the text set with
+// SetText, so it's kept when the design
+// is reloaded (otherwise XML changes
+// are used)
+func (e *Text) SaveState() interface{} {
+	if e.GetText() == e.GetField() {
+		return nil
+	}
+	return e.GetText()
+}
+
+// Function to set the text
+// to another text's (see SaveState)
+func (e *Text) RestoreState(state interface{}) {
+	text, ok := state.(string)
+	if ok {
+		e.SetText(text)
+	}
+}
+
 // Function to unmarshal an XML element into
 // an element. This function is usually only
 // called by xml.Unmarshal
@@ -81,6 +82,17 @@ func (e *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error)
 	return d.Skip()
 }
 
+// Function to check the element
+// for problems
+func (e *Text) Validate() error {
+	// If no text was given, it
+	// can't match the content
+	if e.TextImpl.GetField() == "" {
+		return element.CheckNoContent(e)
+	}
+	return nil
+}
+
 // Function to reset the element
 func (e *Text) Reset() {
 	e.Impl.Reset()
@@ -96,41 +108,23 @@ func (e *Text) IsInitialised() bool {
 		e.TextImpl.IsInitialised()
 }
 
-// Function to initialise the element
-// (load textures, create sprites, set
-// sprite locations, etc.)
-func (e *Text) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
-	// Save the window and parent bounds
-	e.window = window
-	e.parentBounds = bounds
-
-	// Initialise the element
-	err := e.Impl.Init(window, bounds)
+// Function to measure the element
+// (load the font, set the size of the
+// element if it matches the text, etc.)
+func (e *Text) Measure(window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element
+	err := e.Impl.Measure(window, bounds)
 	if err != nil {
 		return err
 	}
 
-	// Initialise the text
-	err = element.InitText(e, &e.TextImpl)
+	// Measure the text
+	err = element.MeasureText(e, &e.TextImpl)
 	if err != nil {
 		return err
 	}
 
-	// If no text was given
-	if e.TextImpl.GetField() == "" {
-		// If it wants to match the content
-		if e.GetRelWidth().MatchContent {
-			return errors.New("invalid width attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		} else if e.GetRelHeight().MatchContent {
-			return errors.New("invalid height attribute value 'match_content' on XML element '" +
-				element.FullName(e, ".", false) +
-				"': no content to match")
-		}
-	}
-
-	return nil
+	return e.Validate()
 }
 
 // Function to draw the element
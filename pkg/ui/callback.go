@@ -0,0 +1,60 @@
+package ui
+
+import "github.com/bhollier/ui/pkg/ui/element"
+
+// Type for the context a design's
+// callback is called with
+type CallbackContext struct {
+	*element.CallbackContext
+	// The design the callback was called
+	// in (or nil, if it isn't known)
+	Design *Design
+}
+
+// Type for a design's callback
+type Callback func(ctx *CallbackContext) error
+
+// Function to convert the callback so it
+// can be added to an element.Registry
+// (e.g. before the design is created).
+// The design is the context's host
+func (c Callback) ToElement() element.Callback {
+	return func(ctx *element.CallbackContext) error {
+		d, _ := ctx.Host.(*Design)
+		return c(&CallbackContext{ctx, d})
+	}
+}
+
+// Type for a design's callback
+// that takes arguments
+type CallbackWithArgs func(ctx *CallbackContext, args ...interface{}) error
+
+// Function to convert the callback so it
+// can be added to an element.Registry
+// (e.g. before the design is created).
+// The design is the context's host
+func (c CallbackWithArgs) ToElement() element.CallbackWithArgs {
+	return func(ctx *element.CallbackContext, args ...interface{}) error {
+		d, _ := ctx.Host.(*Design)
+		return c(&CallbackContext{ctx, d}, args...)
+	}
+}
+
+// Function to add a callback to the
+// design, which is only called by the
+// design's elements. If a callback in
+// the design's registry has the same
+// name, it's overridden
+func (d *Design) RegisterCallback(name string, c Callback) {
+	d.registry.RegisterCallback(name, func(ctx *element.CallbackContext) error {
+		return c(&CallbackContext{ctx, d})
+	})
+}
+
+// Function to add a callback that takes
+// arguments to the design
+func (d *Design) RegisterCallbackWithArgs(name string, c CallbackWithArgs) {
+	d.registry.RegisterCallbackWithArgs(name, func(ctx *element.CallbackContext, args ...interface{}) error {
+		return c(&CallbackContext{ctx, d}, args...)
+	})
+}
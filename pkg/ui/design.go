@@ -1,14 +1,17 @@
 package ui
 
 import (
+	"context"
+	"fmt"
 	_ "github.com/bhollier/ui/pkg/ui/builtin"
 	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/render/gl"
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
 	"log"
 	"net/http"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
 // A UI design, built from an XML file
@@ -17,9 +20,19 @@ type Design struct {
 
 	// The filesystem to use
 	fs http.FileSystem
+	// The filesystem, recording the
+	// files the design uses (see Watch)
+	files *recordingFS
+	// Whether the files are being watched
+	watching bool
 
 	// The design's window
-	window *pixelgl.Window
+	window loopWindow
+	// The window as an element.Window
+	renderer element.Window
+	// The overlay showing the error from
+	// reloading the design (or nil)
+	overlay *errorOverlay
 	// The window's previous
 	// bounds
 	prevWindowBounds pixel.Rect
@@ -29,59 +42,252 @@ type Design struct {
 	// The root element of the design
 	root *element.Root
 
+	// The design's registry
+	registry *element.Registry
+
+	// The dispatcher that sends the
+	// window's input to the elements
+	events *element.EventDispatcher
+
 	// Condition variable for waiting
 	// for the design to be closed
 	waitCondVar *sync.Cond
+
+	// The functions waiting to be called
+	// between frames (see Post), and the
+	// mutex that guards them (so posting
+	// doesn't wait for a frame)
+	posted     []func()
+	postedLock sync.Mutex
+	// Whether the design has stopped, so
+	// posted functions won't be called
+	stopped bool
+
+	// The configuration of the
+	// design's run loop
+	runConfig RunConfig
+
+	// Whether the run loop is running (1 if
+	// it is). Close reads it without locking
+	// the design, as it can be called while
+	// the design is locked (e.g. by a callback)
+	running int32
+	// Channel that's closed to stop
+	// the run loop (see Close)
+	quit      chan struct{}
+	closeOnce sync.Once
+	// Makes sure the window is
+	// only destroyed once
+	destroyOnce sync.Once
+	// The errors from the run loop
+	errs chan error
+	// The functions to call when the
+	// design closes or is resized
+	closeHandlers  []func()
+	resizeHandlers []func(bounds pixel.Rect)
 }
 
 // Function to create a new design from
-// an XML string
-func NewDesign(fs http.FileSystem, path string, windowConfig pixelgl.WindowConfig) (d *Design, err error) {
+// an XML string. The design's elements are
+// created with the given registry (or the
+// default registry, if nil). The design's
+// callbacks can be registered before Init,
+// which fails if the design uses a callback
+// that isn't in either registry
+func NewDesign(fs http.FileSystem, path string, windowConfig pixelgl.WindowConfig,
+	registry *element.Registry) (d *Design, err error) {
 	// Create a new design struct
 	d = new(Design)
 	// The file system
-	d.fs = fs
+	d.files = newRecordingFS(fs)
+	d.fs = d.files
 	// Create the condition variable
 	d.waitCondVar = sync.NewCond(d)
+	// Create the channels for the run loop
+	d.quit = make(chan struct{})
+	d.errs = make(chan error, errorsBufferSize)
 	// The path
 	d.path = path
+	// Create the design's own registry (so
+	// the design's callbacks are only
+	// used by the design)
+	if registry == nil {
+		registry = element.DefaultRegistry()
+	}
+	d.registry = element.NewRegistry(registry)
+	// Create the event dispatcher
+	d.events = element.NewEventDispatcher(d, d.registry.Callbacks())
 
-	// Create the root
-	d.root, err = element.NewRoot(fs, nil, path)
+	// Load the root
+	d.root, err = element.NewRoot(d.fs, nil, d.path, d.registry)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create the window
-	d.window, err = pixelgl.NewWindow(windowConfig)
+	window, err := pixelgl.NewWindow(windowConfig)
 	if err != nil {
 		return nil, err
 	}
+	d.window = pixelWindow{window}
+	d.renderer = gl.NewWindow(window)
 
 	return
 }
 
+// Function to load the design's root,
+// making sure every callback it uses
+// is known
+func (d *Design) load() (*element.Root, error) {
+	root, err := element.NewRoot(d.fs, nil, d.path, d.registry)
+	if err != nil {
+		return nil, err
+	}
+	err = d.validateCallbacks(root)
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Function to make sure every callback
+// the given root uses is known
+func (d *Design) validateCallbacks(root *element.Root) error {
+	return element.ValidateCallbacks(root.Element, d.registry.Callbacks())
+}
+
+// Function to load the design again, keeping
+// the old elements if it fails (with the error
+// shown over them). The new elements get the
+// state of the old elements they match (see
+// element.CopyState). The design must be locked
+func (d *Design) reload() {
+	log.Printf("Loading XML design from '" + d.path + "'...")
+	// Record the files the new elements use
+	prevFiles := d.files.reset()
+	newRoot, err := d.load()
+	if err == nil {
+		// Lay out the new elements
+		d.prevWindowBounds = d.window.Bounds()
+		err = element.InitUI(newRoot.Element, d.renderer, &d.prevWindowBounds)
+	}
+	if err == nil {
+		// Keep the old elements' state (e.g.
+		// how far they're scrolled), laying
+		// out anything it changed
+		element.CopyState(d.root.Element, newRoot.Element)
+		err = element.UpdateUI(newRoot.Element, d.renderer, &d.prevWindowBounds)
+	}
+	if err != nil {
+		// Keep watching the old files
+		// (as the old elements are kept)
+		d.files.restore(prevFiles)
+		err = fmt.Errorf("error reloading XML design: %w", err)
+		d.reportError(err)
+		// Show the error over the design
+		d.overlay = newErrorOverlay(d.renderer, err)
+		if d.root.Element.GetCanvas() != nil {
+			d.overlay.Present(d.root.Element.GetCanvas(), nil)
+		}
+		return
+	}
+	d.root = newRoot
+	// Draw the design (without the overlay)
+	d.overlay = nil
+	element.DrawUI(d.root.Element, d.renderer)
+}
+
+// Function to load the design again
+// (e.g. when a key is pressed) between
+// frames. If it fails, the old elements
+// are kept, and the error is shown over
+// them and sent to Errors
+func (d *Design) Reload() { d.Post(d.reload) }
+
+// Function to get the renderer the
+// design is drawn with, which shows
+// the reload error if there is one
+func (d *Design) output() element.Renderer {
+	if d.overlay != nil {
+		return d.overlay
+	}
+	return d.renderer
+}
+
 // Function to initialise (and draw) the
-// design. This function must be called
-// within pixelgl.Run
+// design, after making sure every callback
+// it uses has been registered (returning
+// an element.UnknownCallbackError if not).
+// This function must be called within
+// pixelgl.Run
 func (d *Design) Init() (err error) {
+	// Check the callbacks
+	err = d.validateCallbacks(d.root)
+	if err != nil {
+		return err
+	}
 	// Update the root node
 	return d.update(d.root)
 }
 
-// Function to start the design with
-// a simple event routine
-func (d *Design) Start() { go d.pollEvents() }
+// Function to start the design's run
+// loop (see SetRunConfig)
+func (d *Design) Start() { d.StartContext(context.Background()) }
 
-// Function to wait for the design to close
-func (d *Design) Wait() {
+// Function to start the design's run loop,
+// which stops (closing the design) when the
+// given context is cancelled. Does nothing
+// if the loop is already running or the
+// design has been closed
+func (d *Design) StartContext(ctx context.Context) {
 	d.Lock()
-	// If the window is already closed
-	if d.window.Closed() {
+	defer d.Unlock()
+	if d.isRunning() || d.isClosing(ctx) {
 		return
 	}
+	d.setRunning(true)
+	go d.run(ctx)
+}
+
+// Function to determine whether
+// the run loop is running
+func (d *Design) isRunning() bool { return atomic.LoadInt32(&d.running) == 1 }
+
+// Function to set whether the run loop is
+// running. The design must be locked (so
+// Wait sees the change)
+func (d *Design) setRunning(running bool) {
+	var value int32
+	if running {
+		value = 1
+	}
+	atomic.StoreInt32(&d.running, value)
+}
+
+// Function to determine whether the
+// run loop should stop, because the
+// window was closed, Close was called
+// or the context was cancelled
+func (d *Design) isClosing(ctx context.Context) bool {
+	select {
+	case <-d.quit:
+		return true
+	case <-ctx.Done():
+		return true
+	default:
+		return d.window.Closed()
+	}
+}
+
+// Function to wait for the
+// design's run loop to stop
+func (d *Design) Wait() {
+	d.Lock()
+	defer d.Unlock()
 	// Wait for the design to close
-	d.waitCondVar.Wait()
+	for d.isRunning() {
+		d.waitCondVar.Wait()
+	}
 }
 
 // Function to start the design then wait
@@ -89,45 +295,46 @@ func (d *Design) Wait() {
 func (d *Design) StartThenWait() { d.Start(); d.Wait() }
 
 // Function to get the design's window
-func (d *Design) Window() *pixelgl.Window { return d.window }
+func (d *Design) Window() *pixelgl.Window {
+	window, _ := d.window.(pixelWindow)
+	return window.Window
+}
+
+// Function to get the design's registry
+func (d *Design) Registry() *element.Registry { return d.registry }
 
 // Function to get the root node of the
 // design
 func (d *Design) Root() element.Element { return d.root.Element }
 
-// Recursive function to search for an
-// element in with the given ID.
-func findElementByID(elem element.Element, id string) element.Element {
-	// If the element is a match
-	if elem.GetID() != nil && *elem.GetID() == id {
-		return elem
-	}
-	// Try to convert it to a layout
-	layout, ok := elem.(element.Layout)
-	if ok {
-		// Do a shallow search
-		elem = layout.GetChildByID(id)
-		if elem != nil {
-			return elem
-		}
-		// Otherwise iterate over the layout's children
-		for i := 0; i < layout.NumChildren(); i++ {
-			// Search the child for the matching element
-			elem = findElementByID(layout.GetChild(i), id)
-			if elem != nil {
-				return elem
-			}
-		}
-	}
-	return nil
-}
-
 // Function to recursively find an element
 // in the design with the given ID. Returns
 // nil if no child could be found
 func (d *Design) FindElementByID(id string) element.Element {
 	// Call the recursive function on the root node
-	return findElementByID(d.root.Element, id)
+	return element.FindElementByID(d.root.Element, id)
+}
+
+// Function to get the top element
+// in the design at the given point
+// (e.g. the mouse's position). Returns
+// nil if there isn't one
+func (d *Design) ElementAt(point pixel.Vec) element.Element {
+	return element.ElementAt(d.root.Element, point)
+}
+
+// Function to get the element in the
+// design with the keyboard focus (or
+// nil, if none do)
+func (d *Design) FocusedElement() element.Element {
+	return d.events.FocusManager().FocusedElement()
+}
+
+// Function to give the keyboard focus
+// to the given element in the design
+// (or take it away, if nil)
+func (d *Design) Focus(elem element.Element) {
+	d.events.FocusManager().Focus(elem)
 }
 
 // Function to update the design
@@ -136,76 +343,13 @@ func (d *Design) update(root *element.Root) error {
 	d.prevWindowBounds = d.window.Bounds()
 
 	// Initialise the design
-	err := element.InitUI(root.Element, d.window, &d.prevWindowBounds)
+	err := element.InitUI(root.Element, d.renderer, &d.prevWindowBounds)
 	if err != nil {
 		return err
 	}
 
 	// Draw the design
-	element.DrawUI(root.Element, d.window)
+	element.DrawUI(root.Element, d.output())
 
 	return nil
 }
-
-// Function to poll the events of the window
-func (d *Design) pollEvents() {
-	// While the window is still open
-	for !d.window.Closed() {
-		// Wait for a new event
-		d.window.UpdateInputWait(time.Second)
-
-		// If the window bounds changed
-		if d.prevWindowBounds != d.window.Bounds() {
-			// Update the design
-			d.Lock()
-			err := d.update(d.root)
-			d.Unlock()
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-
-		// Make sure the window is in focus
-		if d.window.Focused() {
-			// If ctrl + shift + r was pressed
-			if d.window.Pressed(pixelgl.KeyLeftControl) &&
-				d.window.Pressed(pixelgl.KeyLeftShift) &&
-				d.window.JustPressed(pixelgl.KeyR) {
-				// Create a new root root
-				log.Printf("Loading XML design from '" + d.path + "'...")
-				newRoot, err := element.NewRoot(d.fs, nil, d.path)
-				if err != nil {
-					log.Printf("Error reloading XML: %+v", err)
-				}
-
-				// Do an initial design update
-				err = d.update(newRoot)
-				if err != nil {
-					log.Fatal(err)
-				}
-
-				// Set the new root
-				d.Lock()
-				d.root = newRoot
-				d.Unlock()
-			}
-
-			// Tell the root element
-			// there was a new event
-			d.Lock()
-			/*go */
-			d.root.NewEvent(d.window)
-
-			// Draw the design
-			element.DrawUI(d.root.Element, d.window)
-			d.Unlock()
-		}
-
-		// Wait a bit before the next event
-		// time.Sleep(time.Second / 50)
-	}
-
-	// Broadcast to any threads
-	// waiting for the design to close
-	d.waitCondVar.Broadcast()
-}
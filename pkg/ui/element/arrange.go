@@ -0,0 +1,312 @@
+package element
+
+import (
+	"errors"
+	"github.com/faiface/pixel"
+	"github.com/xlab/treeprint"
+)
+
+// Type for the computed bounds of an
+// element and its children
+type LayoutNode struct {
+	// The element itself
+	Element Element
+	// The element's bounds
+	Bounds pixel.Rect
+	// The element's children (if
+	// the element is a layout)
+	Children []*LayoutNode
+}
+
+// Function to measure then arrange an
+// element within the given bounds. This
+// function is generally used by layouts
+// to lay out their children
+func MeasureAndArrange(e Element, window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the element
+	err := e.Measure(window, bounds)
+	if err != nil {
+		return err
+	}
+	// Arrange it
+	return e.Arrange(window, bounds)
+}
+
+// Function to create a tree of the
+// bounds of an element and its
+// children. Returns nil if the
+// element hasn't been arranged
+func NewLayoutNode(e Element) *LayoutNode {
+	// If the element hasn't been arranged
+	if e.GetBounds() == nil {
+		return nil
+	}
+	node := &LayoutNode{Element: e, Bounds: *e.GetBounds()}
+
+	// Try to convert to a layout
+	layout, ok := e.(Layout)
+	// If it is a layout, iterate over the children
+	if ok {
+		node.Children = make([]*LayoutNode, 0, layout.NumChildren())
+		for i := 0; i < layout.NumChildren(); i++ {
+			child := NewLayoutNode(layout.GetChild(i))
+			if child != nil {
+				node.Children = append(node.Children, child)
+			}
+		}
+	}
+	return node
+}
+
+// Interface type for the parts of
+// Impl that keep track of which
+// elements have an invalid layout
+type layoutValidity interface {
+	// Function to mark one of the
+	// element's descendant's layout
+	// as invalid
+	invalidateChildLayout()
+	// Function to determine whether
+	// the element's own layout has
+	// been invalidated
+	layoutInvalidated() bool
+	// Function to mark the element's
+	// layout as valid again
+	validateLayout()
+}
+
+// Function to get the elements in a
+// tree whose own layout has been
+// invalidated
+func invalidatedElements(e Element) (elems []Element) {
+	// If nothing in the tree is invalid, stop
+	if !e.NeedsLayout() {
+		return
+	}
+	lv, ok := e.(layoutValidity)
+	if ok && lv.layoutInvalidated() {
+		elems = append(elems, e)
+	}
+	// If it's a layout, check the children
+	layout, ok := e.(Layout)
+	if ok {
+		for i := 0; i < layout.NumChildren(); i++ {
+			elems = append(elems, invalidatedElements(layout.GetChild(i))...)
+		}
+	}
+	return
+}
+
+// Function to mark the layout of
+// every element in a tree as valid
+func validateLayout(e Element) {
+	// If nothing in the tree is invalid, stop
+	if !e.NeedsLayout() {
+		return
+	}
+	lv, ok := e.(layoutValidity)
+	if ok {
+		lv.validateLayout()
+	}
+	// If it's a layout, validate the children
+	layout, ok := e.(Layout)
+	if ok {
+		for i := 0; i < layout.NumChildren(); i++ {
+			validateLayout(layout.GetChild(i))
+		}
+	}
+}
+
+// Function to measure the sizes in the
+// given (dependency) order, then arrange
+// the element tree from the root
+func solveLayout(root Element, order []LayoutDependency,
+	window pixel.Rect, bounds *pixel.Rect) error {
+	// Measure the elements in order, so
+	// every size that doesn't depend on an
+	// element's bounds is known in one pass
+	for _, dep := range order {
+		if dep.Property == LayoutBounds {
+			continue
+		}
+		var measureBounds *pixel.Rect
+		if dep.Element == root {
+			measureBounds = bounds
+		}
+		err := dep.Element.Measure(window, measureBounds)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Arrange the tree. As every size that
+	// doesn't match the bounds is known, each
+	// layout can arrange its children in one
+	// pass (a layout whose children depend on
+	// each other arranges them in dependency
+	// order, like relative.Layout), and sizes
+	// that match the bounds are set by the
+	// parent as it arranges them
+	err := MeasureAndArrange(root, window, bounds)
+	if err != nil {
+		return err
+	}
+	if !root.IsArranged() {
+		return errors.New("element layout failed. " +
+			"The following element(s) couldn't be arranged: \n" +
+			ElementTree(root, func(e Element) bool {
+				return !e.IsArranged()
+			}))
+	}
+
+	// The layout is valid again
+	validateLayout(root)
+	return nil
+}
+
+// Function to reset and lay out the
+// entire UI element tree (by traversing
+// up the given element's parents)
+// within the given window bounds,
+// without creating any canvases.
+// Returns the bounds of every element,
+// or a LayoutCycleError if the tree's
+// sizes depend on each other in a loop
+func LayoutUI(e Element, window pixel.Rect, bounds *pixel.Rect) (*LayoutNode, error) {
+	// While the element has a parent, go up the tree
+	for e.GetParent() != nil {
+		e = e.GetParent()
+	}
+
+	// Reset the element (and its children)
+	e.Reset()
+
+	// Sort the tree's sizes and bounds so
+	// each comes after the ones it depends on
+	order, err := NewLayoutGraph(e).Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	// Lay out the tree
+	err = solveLayout(e, order, window, bounds)
+	if err != nil {
+		return nil, err
+	}
+	return NewLayoutNode(e), nil
+}
+
+// Function to lay out only the parts of
+// the UI element tree (found by traversing
+// up the given element's parents) that
+// have been invalidated, along with
+// anything whose size or bounds depend
+// on them. Everything else keeps its
+// size, and is only moved if its bounds
+// changed
+func RelayoutUI(e Element, window pixel.Rect, bounds *pixel.Rect) (*LayoutNode, error) {
+	// While the element has a parent, go up the tree
+	for e.GetParent() != nil {
+		e = e.GetParent()
+	}
+
+	// If nothing needs laying out, there's nothing to do
+	if !e.NeedsLayout() {
+		return NewLayoutNode(e), nil
+	}
+
+	// Sort the tree's sizes and bounds so
+	// each comes after the ones it depends on
+	graph := NewLayoutGraph(e)
+	order, err := graph.Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the properties of the invalidated
+	// elements, and everything depending on them
+	invalidated := make([]LayoutDependency, 0)
+	for _, elem := range invalidatedElements(e) {
+		invalidated = append(invalidated,
+			LayoutDependency{elem, LayoutWidth},
+			LayoutDependency{elem, LayoutHeight},
+			LayoutDependency{elem, LayoutBounds})
+	}
+	affected := graph.Dependents(invalidated)
+
+	// Reset the affected properties
+	affectedOrder := make([]LayoutDependency, 0, len(affected))
+	for _, dep := range order {
+		if !affected[dep] {
+			continue
+		}
+		affectedOrder = append(affectedOrder, dep)
+		elem := dep.Element
+
+		// If the size is affected
+		if dep.Property != LayoutBounds {
+			// If it's a layout, only reset the size (and the
+			// background, which may have been made for the
+			// old size) as the children may be unaffected
+			_, ok := elem.(Layout)
+			if ok {
+				if dep.Property == LayoutWidth {
+					elem.SetActualWidth(nil)
+				} else {
+					elem.SetActualHeight(nil)
+				}
+				bkg := elem.GetBkg()
+				if bkg.IsSVG() {
+					bkg.SetSprite(nil)
+				}
+			} else {
+				elem.Reset()
+			}
+		}
+
+		// Either way, the element needs arranging again
+		elem.SetMin(nil)
+		elem.SetMax(nil)
+	}
+
+	// Lay out the tree
+	err = solveLayout(e, affectedOrder, window, bounds)
+	if err != nil {
+		return nil, err
+	}
+	return NewLayoutNode(e), nil
+}
+
+// Function to print the tree of elements
+// that match the given predicate. A layout
+// is only included if it matches
+func ElementTree(e Element, match func(Element) bool) string {
+	// Make a tree of the matching elements
+	tree := treeprint.New()
+
+	// Recursive function to find matching elements
+	var getElements func(treeprint.Tree, Element)
+	getElements = func(branch treeprint.Tree, e Element) {
+		// If the element matches
+		if match(e) {
+			elemName := Name(e, true)
+			// Try to convert to a layout
+			layout, ok := e.(Layout)
+			// If it is a layout, iterate over the children
+			if ok {
+				newBranch := branch.AddBranch(elemName)
+				for i := 0; i < layout.NumChildren(); i++ {
+					// Get the child element's matching elements
+					getElements(newBranch, layout.GetChild(i))
+				}
+			} else {
+				branch.AddNode(elemName)
+			}
+		}
+	}
+
+	// Call the function on root
+	getElements(tree, e)
+
+	return tree.String()
+}
@@ -0,0 +1,231 @@
+package element_test
+
+import (
+	"github.com/bhollier/ui/pkg/ui/builtin"
+	_ "github.com/bhollier/ui/pkg/ui/builtin/layout"
+	_ "github.com/bhollier/ui/pkg/ui/builtin/layout/relative"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/util"
+	"github.com/faiface/pixel"
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+// The namespace of the builtin elements
+const ns = `xmlns:builtin="http://github.com/bhollier/ui/api/schema"`
+
+// Function to load a design from
+// the given XML, failing the test
+// if it can't be loaded
+func loadDesign(t *testing.T, design string) element.Element {
+	t.Helper()
+	fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(design)}})
+	root, err := element.NewRoot(fs, nil, "design.xml", nil)
+	if err != nil {
+		t.Fatalf("error loading design: %v", err)
+	}
+	return root.Element
+}
+
+// Function to get the rectangle an
+// element was laid out in
+func elementRect(e element.Element) pixel.Rect {
+	if e.GetMin() == nil || e.GetMax() == nil {
+		return pixel.Rect{}
+	}
+	return pixel.Rect{Min: *e.GetMin(), Max: *e.GetMax()}
+}
+
+// Function to get an element's
+// child, by its child indices
+func child(e element.Element, path ...int) element.Element {
+	for _, i := range path {
+		e = e.(element.Layout).GetChild(i)
+	}
+	return e
+}
+
+func TestLayoutUI(t *testing.T) {
+	window := pixel.R(0, 0, 200, 100)
+
+	// The rectangles the elements (by
+	// their child indices) are laid out in
+	type want struct {
+		path []int
+		rect pixel.Rect
+	}
+	tests := []struct {
+		name   string
+		design string
+		want   []want
+	}{
+		{
+			name: "match_parent",
+			design: `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+				<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#FF0000"/>
+				<LinearLayout builtin:width="50px" builtin:height="30px">
+					<Image builtin:width="match_parent" builtin:height="match_parent" builtin:source="#FF0000"/>
+				</LinearLayout>
+			</LinearLayout>`,
+			want: []want{
+				{nil, pixel.R(0, 0, 200, 100)},
+				{[]int{0}, pixel.R(0, 80, 200, 100)},
+				{[]int{1}, pixel.R(0, 50, 50, 80)},
+				{[]int{1, 0}, pixel.R(0, 50, 50, 80)},
+			},
+		},
+		{
+			name: "match_content",
+			design: `<LinearLayout ` + ns + ` builtin:width="match_content" builtin:height="match_content">
+				<Image builtin:width="30px" builtin:height="10px" builtin:source="#FF0000"/>
+				<Image builtin:width="20px" builtin:height="15px" builtin:source="#FF0000"/>
+			</LinearLayout>`,
+			want: []want{
+				{nil, pixel.R(0, 75, 30, 100)},
+				{[]int{0}, pixel.R(0, 90, 30, 100)},
+				{[]int{1}, pixel.R(0, 75, 20, 90)},
+			},
+		},
+		{
+			name: "percent",
+			design: `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="50%">
+				<Image builtin:width="50%" builtin:height="40%" builtin:source="#FF0000"/>
+			</LinearLayout>`,
+			want: []want{
+				{nil, pixel.R(0, 50, 200, 100)},
+				{[]int{0}, pixel.R(0, 80, 100, 100)},
+			},
+		},
+		{
+			// The first image is relative to the
+			// second, so it's arranged after it
+			name: "relative",
+			design: `<RelativeLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+				<Image builtin:id="left" builtin:width="match_bounds" builtin:height="40px"
+					builtin:right-of="parent" builtin:left-of="right" builtin:source="#FF0000"/>
+				<Image builtin:id="right" builtin:width="30px" builtin:height="60px"
+					builtin:right-of="parent" builtin:source="#00FF00"/>
+			</RelativeLayout>`,
+			want: []want{
+				{nil, pixel.R(0, 0, 200, 100)},
+				{[]int{0}, pixel.R(0, 60, 170, 100)},
+				{[]int{1}, pixel.R(170, 40, 200, 100)},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := loadDesign(t, test.design)
+			bounds := window
+			node, err := element.LayoutUI(root, window, &bounds)
+			if err != nil {
+				t.Fatalf("error laying out design: %v", err)
+			}
+			if node == nil || node.Element != root {
+				t.Fatalf("got layout node %v, want the root's", node)
+			}
+			for _, w := range test.want {
+				got := elementRect(child(root, w.path...))
+				if got != w.rect {
+					t.Errorf("element %v: got %v, want %v", w.path, got, w.rect)
+				}
+			}
+		})
+	}
+}
+
+// Function to change an image's size
+// without invalidating its layout
+func setImageSize(t *testing.T, e element.Element, width, height string) {
+	t.Helper()
+	img := e.(*builtin.Image)
+	var err error
+	img.RelativeWidth, err = util.ParseRelativeSize(width)
+	if err != nil {
+		t.Fatalf("error parsing width: %v", err)
+	}
+	img.RelativeHeight, err = util.ParseRelativeSize(height)
+	if err != nil {
+		t.Fatalf("error parsing height: %v", err)
+	}
+}
+
+func TestRelayoutUI(t *testing.T) {
+	window := pixel.R(0, 0, 100, 100)
+	root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">
+		<Image builtin:width="50px" builtin:height="10px" builtin:source="#FF0000"/>
+		<Image builtin:width="50px" builtin:height="10px" builtin:source="#00FF00"/>
+		<Image builtin:width="50px" builtin:height="10px" builtin:source="#0000FF"/>
+	</LinearLayout>`)
+	bounds := window
+	_, err := element.LayoutUI(root, window, &bounds)
+	if err != nil {
+		t.Fatalf("error laying out design: %v", err)
+	}
+
+	// The first image is changed without being
+	// invalidated, so if it's laid out again
+	// its bounds change
+	setImageSize(t, child(root, 0), "80px", "10px")
+	// The second image grows, which moves
+	// the third (but not the first)
+	setImageSize(t, child(root, 1), "50px", "30px")
+	child(root, 1).InvalidateLayout()
+	if !root.NeedsLayout() {
+		t.Fatalf("the root doesn't need layout after a child was invalidated")
+	}
+
+	// The rectangles the elements (by
+	// their child indices) are laid out in
+	type want struct {
+		path []int
+		rect pixel.Rect
+	}
+	steps := []struct {
+		name string
+		// The element to invalidate (or
+		// nil, if nothing is invalidated)
+		invalidate []int
+		want       []want
+	}{
+		{"relayout", nil, []want{
+			{nil, pixel.R(0, 0, 100, 100)},
+			{[]int{0}, pixel.R(0, 90, 50, 100)},
+			{[]int{1}, pixel.R(0, 60, 50, 90)},
+			{[]int{2}, pixel.R(0, 50, 50, 60)},
+		}},
+		// Nothing is invalid anymore, so
+		// laying out again changes nothing
+		{"nothing invalid", nil, []want{
+			{[]int{0}, pixel.R(0, 90, 50, 100)},
+		}},
+		{"first image invalidated", []int{0}, []want{
+			{[]int{0}, pixel.R(0, 90, 80, 100)},
+			{[]int{1}, pixel.R(0, 60, 50, 90)},
+			{[]int{2}, pixel.R(0, 50, 50, 60)},
+		}},
+	}
+	for _, step := range steps {
+		if step.invalidate != nil {
+			child(root, step.invalidate...).InvalidateLayout()
+		}
+		bounds := window
+		node, err := element.RelayoutUI(root, window, &bounds)
+		if err != nil {
+			t.Fatalf("%s: error laying out design: %v", step.name, err)
+		}
+		if node == nil || node.Element != root {
+			t.Fatalf("%s: got layout node %v, want the root's", step.name, node)
+		}
+		if root.NeedsLayout() {
+			t.Errorf("%s: the root still needs layout", step.name)
+		}
+		for _, w := range step.want {
+			got := elementRect(child(root, w.path...))
+			if got != w.rect {
+				t.Errorf("%s: element %v: got %v, want %v", step.name, w.path, got, w.rect)
+			}
+		}
+	}
+}
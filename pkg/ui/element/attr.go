@@ -8,8 +8,10 @@ import (
 	"image/color"
 	"math/bits"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Type for an attribute parser
@@ -18,25 +20,21 @@ type AttrParser func(attr string) (reflect.Value, error)
 // Type for an attribute parser map
 type attributeTypesMap map[reflect.Type]AttrParser
 
-// Map of attribute types, with the
-// key being the attribute's (reflect)
-// type
-var attributeTypes attributeTypesMap
-
 // Function to initialise the attributes types map
 func init() {
 	// Create the attributes types map,
 	// with all the primitive types (except
 	// uintptr, complex32 and complex64) and
-	// the util.* types
-	attributeTypes = attributeTypesMap{
+	// the util.* types, in the default registry
+	defaultRegistry.attributeTypes = attributeTypesMap{
 		// "Parsing" a string (function just spits it back)
 		reflect.TypeOf((*string)(nil)).Elem(): func(attr string) (reflect.Value, error) {
 			return reflect.ValueOf(attr), nil
 		},
-		// Parsing a boolean type
+		// Parsing a boolean type (ignoring the
+		// case and whitespace, like the other types)
 		reflect.TypeOf((*bool)(nil)).Elem(): func(attr string) (reflect.Value, error) {
-			val, err := strconv.ParseBool(attr)
+			val, err := strconv.ParseBool(strings.ToLower(strings.TrimSpace(attr)))
 			if err != nil {
 				return reflect.Value{}, err
 			}
@@ -154,6 +152,15 @@ func init() {
 			}
 			return reflect.ValueOf(val), nil
 		},
+		// Parsing a "time.Duration" type
+		// (e.g. "500ms")
+		reflect.TypeOf((*time.Duration)(nil)).Elem(): func(attr string) (reflect.Value, error) {
+			val, err := time.ParseDuration(attr)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(val), nil
+		},
 		// Parsing a "color.RGBA" type
 		reflect.TypeOf((*color.RGBA)(nil)).Elem(): func(attr string) (reflect.Value, error) {
 			val, err := util.ParseColor(attr)
@@ -223,61 +230,46 @@ func init() {
 	}
 }
 
-// Function to register an
-// attribute type
+// Function to register an attribute
+// type in the default registry
 func RegisterAttrType(t reflect.Type, p AttrParser) {
-	// Register the attribute type
-	attributeTypes[t] = p
+	defaultRegistry.RegisterAttrType(t, p)
 }
 
-// Function to parse an attribute
-// string into the given type
+// Function to parse an attribute string
+// into the given type, using the default
+// registry
 func ParseAttr(t reflect.Type, v string) (reflect.Value, error) {
-	// Try to get the attribute factory
-	parser, ok := attributeTypes[t]
-	// If it was found, call the parser
-	if ok {
-		return parser(v)
-	} else {
-		return reflect.Value{}, errors.New(
-			"unknown attribute type '" + t.Name() + "'")
-	}
+	return defaultRegistry.ParseAttr(t, v)
 }
 
-// Function to parse the given xml
-// attributes and set the fields of
-// the given element using uixml tags.
-// This function searches for tags
-// recursively. It does not support
-// arrays or maps
-func SetAttrs(e Element, attrs []xml.Attr) error {
-	// Get the element's type info
-	t := reflect.TypeOf(e).Elem()
-	v := reflect.ValueOf(e).Elem()
-
-	// Firstly, look for a namespace attribute
-	for _, attr := range attrs {
-		if attr.Name.Space == "xmlns" {
-			// Add the namespace to the element
-			e.AddNamespace(attr.Value)
-		} else if attr.Name.Local == "xmlns" {
-			e.AddNamespace(attr.Name.Space)
-		}
-	}
+// Type for a struct field with a uixml tag
+type attrField struct {
+	// The attribute's XML name
+	Name xml.Name
+	// The field's index (see
+	// reflect.Value.FieldByIndex)
+	Index []int
+	// The field's type
+	Type reflect.Type
+	// Whether the attribute is optional
+	Optional bool
+}
 
-	// Create a map of fields
-	type Field struct {
-		Name     string
-		Value    reflect.Value
-		Optional bool
-		Set      bool
-	}
-	fields := make(map[xml.Name]*Field, 0)
+// Function to find the fields of the given
+// struct type with uixml tags. This function
+// searches for tags recursively, through
+// fields without a tag that are structs
+func findAttrFields(t reflect.Type) ([]attrField, error) {
+	fields := make([]attrField, 0)
 
-	var findFieldsWithTag func(t reflect.Type, v reflect.Value) error
-	findFieldsWithTag = func(t reflect.Type, v reflect.Value) error {
-		// Iterate over the element's fields
+	var findFieldsWithTag func(t reflect.Type, index []int) error
+	findFieldsWithTag = func(t reflect.Type, index []int) error {
+		// Iterate over the struct's fields
 		for i := 0; i < t.NumField(); i++ {
+			// Get the field's index from the top
+			fieldIndex := append(append([]int{}, index...), i)
+
 			// Try to get the field's uixml tag
 			tag, ok := t.Field(i).Tag.Lookup("uixml")
 			// If it's found
@@ -289,11 +281,10 @@ func SetAttrs(e Element, attrs []xml.Attr) error {
 				}
 
 				// Create a field with the default values
-				field := Field{
-					Name:     t.Field(i).Name,
-					Value:    v.Field(i),
+				field := attrField{
+					Index:    fieldIndex,
+					Type:     t.Field(i).Type,
 					Optional: false,
-					Set:      false,
 				}
 
 				// Split the tag by commas
@@ -301,16 +292,15 @@ func SetAttrs(e Element, attrs []xml.Attr) error {
 
 				// Parse the name of the attribute
 				spaceIndex := strings.Index(commaSepList[0], " ")
-				var attrName xml.Name
 				if spaceIndex != -1 {
 					// Set the namespace
-					attrName.Space = commaSepList[0][:spaceIndex]
+					field.Name.Space = commaSepList[0][:spaceIndex]
 					// Set the local
-					attrName.Local = commaSepList[0][spaceIndex+1:]
+					field.Name.Local = commaSepList[0][spaceIndex+1:]
 
 					// Otherwise just set local as the whole name
 				} else {
-					attrName.Local = commaSepList[0]
+					field.Name.Local = commaSepList[0]
 				}
 
 				// Iterate over all the tokens but the first
@@ -324,18 +314,17 @@ func SetAttrs(e Element, attrs []xml.Attr) error {
 						// Otherwise the token is unknown and so return an error
 					default:
 						return errors.New("unknown token '" + commaSepList[j] +
-							"' in uixml tag on XML element '" +
-							FullName(e, ".", false) + "'")
+							"' in uixml tag on field '" + t.Field(i).Name + "'")
 					}
 				}
-				// Add the field to the map
-				fields[attrName] = &field
+				// Add the field
+				fields = append(fields, field)
 
 				// If it doesn't have a tag but
 				// has subfields that might have a tag
-			} else if v.Field(i).Kind() == reflect.Struct {
+			} else if t.Field(i).Type.Kind() == reflect.Struct {
 				// Find fields in the struct
-				err := findFieldsWithTag(t.Field(i).Type, v.Field(i))
+				err := findFieldsWithTag(t.Field(i).Type, fieldIndex)
 				if err != nil {
 					return err
 				}
@@ -344,10 +333,53 @@ func SetAttrs(e Element, attrs []xml.Attr) error {
 		return nil
 	}
 
+	err := findFieldsWithTag(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Function to parse the given xml
+// attributes and set the fields of
+// the given element using uixml tags.
+// This function searches for tags
+// recursively. It does not support
+// arrays or maps
+func SetAttrs(e Element, attrs []xml.Attr) error {
+	// Get the element's type info
+	t := reflect.TypeOf(e).Elem()
+	v := reflect.ValueOf(e).Elem()
+
+	// Firstly, look for a namespace attribute
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" {
+			// Add the namespace to the element
+			e.AddNamespace(attr.Value)
+		} else if attr.Name.Local == "xmlns" {
+			e.AddNamespace(attr.Name.Space)
+		}
+	}
+
+	// Create a map of fields
+	type Field struct {
+		Value    reflect.Value
+		Optional bool
+		Set      bool
+	}
+	fields := make(map[xml.Name]*Field, 0)
+
 	// Find fields
-	err := findFieldsWithTag(t, v)
+	attrFields, err := findAttrFields(t)
 	if err != nil {
-		return err
+		return errors.New(err.Error() + " on XML element '" +
+			FullName(e, ".", false) + "'")
+	}
+	for _, attrField := range attrFields {
+		fields[attrField.Name] = &Field{
+			Value:    v.FieldByIndex(attrField.Index),
+			Optional: attrField.Optional,
+		}
 	}
 
 	// Iterate over the attributes
@@ -379,17 +411,28 @@ func SetAttrs(e Element, attrs []xml.Attr) error {
 
 			// If it still wasn't found
 			if !ok {
-				return errors.New("unknown attribute '" + XMLNameToString(attr.Name) +
-					"' on XML element '" + FullName(e, ".", false) + "'")
+				err = ReportLoadError(errors.New("unknown attribute '"+
+					XMLNameToString(attr.Name)+"' on XML element '"+
+					FullName(e, ".", false)+"'"), e)
+				if err != nil {
+					return err
+				}
+				continue
 			}
 		}
 
 		// Try to parse the attribute
-		val, err := ParseAttr(field.Value.Type(), attr.Value)
+		val, err := e.GetRegistry().ParseAttr(field.Value.Type(), attr.Value)
 		if err != nil {
-			return errors.New(fmt.Sprintf("error parsing attribute '"+
+			err = ReportLoadError(errors.New(fmt.Sprintf("error parsing attribute '"+
 				XMLNameToString(attr.Name)+"' on XML element '"+
-				FullName(e, ".", false)+"': %+v", err))
+				FullName(e, ".", false)+"': %+v", err)), e)
+			if err != nil {
+				return err
+			}
+			// It was given, so it isn't missing
+			field.Set = true
+			continue
 		}
 		// Otherwise set the value
 		field.Value.Set(val)
@@ -397,12 +440,21 @@ func SetAttrs(e Element, attrs []xml.Attr) error {
 		field.Set = true
 	}
 
-	// Iterate over the fields
+	// Get the fields that aren't optional
+	// but weren't set (in order, so the
+	// errors are always the same)
+	missing := make([]string, 0)
 	for attrName, field := range fields {
-		// If the field isn't optional but wasn't set
 		if !field.Optional && !field.Set {
-			return errors.New("no '" + XMLNameToString(attrName) + "' attribute on " +
-				"XML element '" + FullName(e, ".", false) + "'")
+			missing = append(missing, XMLNameToString(attrName))
+		}
+	}
+	sort.Strings(missing)
+	for _, attrName := range missing {
+		err = ReportLoadError(errors.New("no '"+attrName+"' attribute on "+
+			"XML element '"+FullName(e, ".", false)+"'"), e)
+		if err != nil {
+			return err
 		}
 	}
 
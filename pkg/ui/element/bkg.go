@@ -151,7 +151,7 @@ func InitBkg(e Element, i Image) error {
 // of an element. This function
 // should be called first
 func DrawBkg(e Element, b Image) {
-	// Clear the canvas (with a transparent background)
+	// Clear the canvas (or its clip) with a transparent background
 	// (this is why it's important the background is
 	// drawn first)
 	e.GetCanvas().Clear(color.Transparent)
@@ -4,9 +4,9 @@ import (
 	"encoding/xml"
 	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
 	"log"
 	"net/http"
+	"time"
 )
 
 // A button's state
@@ -22,6 +22,32 @@ const ButtonHoveredState = "hovered"
 // mouse is pressing the button
 const ButtonPressedState = "pressed"
 
+// The button state when the button
+// has the keyboard focus
+const ButtonFocusedState = "focused"
+
+// The button state when the
+// button is disabled
+const ButtonDisabledState = "disabled"
+
+// The default longest time between
+// two clicks for them to count as a
+// double click
+const DefaultDoubleClickTime = 500 * time.Millisecond
+
+// The default time the button has to
+// be held for to count as a long press
+const DefaultLongPressTime = 800 * time.Millisecond
+
+// The button states that have
+// their own background
+var buttonBkgStates = []ButtonState{
+	ButtonHoveredState,
+	ButtonPressedState,
+	ButtonFocusedState,
+	ButtonDisabledState,
+}
+
 // Interface for a button element
 type Button interface {
 	// A button is an element
@@ -34,6 +60,14 @@ type Button interface {
 	// current state
 	SetButtonState(s ButtonState)
 
+	// Function to determine whether
+	// the button is enabled
+	IsEnabled() bool
+	// Function to enable or disable
+	// the button. A disabled button
+	// ignores any input
+	SetEnabled(bool)
+
 	// Function to get the button's
 	// background field from XML for
 	// the given state
@@ -49,7 +83,11 @@ type Button interface {
 	SetButtonBkg(state ButtonState, sprite *pixel.Sprite)
 
 	// Function to call the press callback
-	CallPressCallback() error
+	CallPressCallback(ev Event) error
+
+	// Function to get the button's
+	// implementation
+	buttonImpl() *ButtonImpl
 }
 
 // Type for a button. Note, structs
@@ -62,19 +100,84 @@ type ButtonImpl struct {
 	// The button's current state
 	state ButtonState
 
+	// Whether the mouse is over the button
+	hovered bool
+	// Whether the button is being pressed
+	pressed bool
+	// Whether the button has the focus
+	focused bool
+	// Whether the button was enabled or
+	// disabled with SetEnabled (rather
+	// than from XML)
+	enabledSet bool
+
+	// The mouse buttons pressed on the
+	// button (which haven't been released
+	// or cancelled), and when they were
+	pressTimes map[MouseButton]time.Time
+	// Whether the button is being
+	// pressed with the keyboard
+	keyPressed bool
+	// Whether the current press has
+	// become a long press
+	longPressed bool
+	// When the button was last clicked
+	// (or zero, if the next click can't
+	// be a double click)
+	lastClick time.Time
+
+	// Whether the button is enabled
+	Enabled bool `uixml:"http://github.com/bhollier/ui/api/schema enabled,optional"`
+
 	// The button's background when
 	// being hovered over from XML
 	HoveredBackground string `uixml:"http://github.com/bhollier/ui/api/schema bkg-hovered,optional"`
 	// The button's background when
 	// being pressed from XML
 	PressedBackground string `uixml:"http://github.com/bhollier/ui/api/schema bkg-pressed,optional"`
+	// The button's background when
+	// focused from XML
+	FocusedBackground string `uixml:"http://github.com/bhollier/ui/api/schema bkg-focused,optional"`
+	// The button's background when
+	// disabled from XML
+	DisabledBackground string `uixml:"http://github.com/bhollier/ui/api/schema bkg-disabled,optional"`
 
 	// The button's background
 	// sprites for each state
 	backgrounds map[ButtonState]*pixel.Sprite
 
-	// The element's press callback
+	// The element's press callback, called
+	// when the button is pressed and released
+	// with the left mouse button (without
+	// the mouse leaving it) or the keyboard
 	PressCallback string `uixml:"http://github.com/bhollier/ui/api/schema press-callback,optional"`
+	// The element's release callback, called
+	// whenever a press on the button ends
+	// with the button being released
+	ReleaseCallback string `uixml:"http://github.com/bhollier/ui/api/schema release-callback,optional"`
+	// The element's click callback, called
+	// when the button is clicked with the
+	// left mouse button
+	ClickCallback string `uixml:"http://github.com/bhollier/ui/api/schema click-callback,optional"`
+	// The element's double click callback
+	DoubleClickCallback string `uixml:"http://github.com/bhollier/ui/api/schema double-click-callback,optional"`
+	// The element's long press callback,
+	// called when the left mouse button
+	// is held on the button (in which case
+	// the press isn't a click)
+	LongPressCallback string `uixml:"http://github.com/bhollier/ui/api/schema long-press-callback,optional"`
+	// The element's right click callback
+	RightClickCallback string `uixml:"http://github.com/bhollier/ui/api/schema right-click-callback,optional"`
+	// The element's middle click callback
+	MiddleClickCallback string `uixml:"http://github.com/bhollier/ui/api/schema middle-click-callback,optional"`
+
+	// The longest time between two
+	// clicks for them to count as
+	// a double click
+	DoubleClickTime time.Duration `uixml:"http://github.com/bhollier/ui/api/schema double-click-time,optional"`
+	// The time the button has to be held
+	// for to count as a long press
+	LongPressTime time.Duration `uixml:"http://github.com/bhollier/ui/api/schema long-press-time,optional"`
 }
 
 // Function to create a button element
@@ -82,11 +185,21 @@ func NewButton(fs http.FileSystem, name xml.Name, parent Layout) ButtonImpl {
 	e := ButtonImpl{Impl: NewElement(fs, name, parent)}
 	// Set the state as the default
 	e.state = ButtonDefaultState
+	// Buttons are enabled and can
+	// be focused by default
+	e.Enabled = true
+	e.Focusable = true
+	// Set the default thresholds
+	e.DoubleClickTime = DefaultDoubleClickTime
+	e.LongPressTime = DefaultLongPressTime
+	e.pressTimes = make(map[MouseButton]time.Time)
 	// Create the backgrounds map
 	e.backgrounds = map[ButtonState]*pixel.Sprite{
-		ButtonDefaultState: nil,
-		ButtonHoveredState: nil,
-		ButtonPressedState: nil,
+		ButtonDefaultState:  nil,
+		ButtonHoveredState:  nil,
+		ButtonPressedState:  nil,
+		ButtonFocusedState:  nil,
+		ButtonDisabledState: nil,
 	}
 	return e
 }
@@ -103,16 +216,104 @@ func (e *ButtonImpl) SetButtonState(s ButtonState) {
 	e.GetBkg().SetSprite(e.backgrounds[e.state])
 }
 
+// Function to determine whether
+// the button is enabled
+func (e *ButtonImpl) IsEnabled() bool { return e.Enabled }
+
+// Function to enable or disable
+// the button
+func (e *ButtonImpl) SetEnabled(enabled bool) {
+	e.Enabled = enabled
+	e.enabledSet = true
+	// A disabled button can't stay pressed
+	if !enabled {
+		e.cancelPress()
+	}
+	// Update the state
+	if e.state != e.inputState() {
+		e.SetButtonState(e.inputState())
+		e.InvalidatePaint()
+	}
+}
+
+// Type for the state of a button
+// that's kept when the design is
+// reloaded (the rest comes from
+// the input)
+type buttonState struct {
+	// Whether the button is enabled
+	enabled bool
+}
+
+// Function to get whether the button was
+// enabled or disabled with SetEnabled, so
+// it stays that way when the design is
+// reloaded (otherwise XML changes are used)
+func (e *ButtonImpl) SaveState() interface{} {
+	if !e.enabledSet {
+		return nil
+	}
+	return buttonState{enabled: e.Enabled}
+}
+
+// Function to enable or disable the
+// button like another button (see SaveState)
+func (e *ButtonImpl) RestoreState(state interface{}) {
+	button, ok := state.(buttonState)
+	if ok {
+		e.SetEnabled(button.enabled)
+	}
+}
+
+// Function to determine whether the
+// button can have the keyboard focus
+// (only if it's enabled)
+func (e *ButtonImpl) IsFocusable() bool { return e.Impl.IsFocusable() && e.Enabled }
+
+// Function to cancel any presses
+// on the button
+func (e *ButtonImpl) cancelPress() {
+	e.pressed = false
+	e.keyPressed = false
+	e.pressTimes = make(map[MouseButton]time.Time)
+}
+
+// Function to get the state the
+// button's input puts it in
+func (e *ButtonImpl) inputState() ButtonState {
+	switch {
+	case !e.Enabled:
+		return ButtonDisabledState
+	case e.pressed:
+		return ButtonPressedState
+	case e.hovered:
+		return ButtonHoveredState
+	case e.focused:
+		return ButtonFocusedState
+	default:
+		return ButtonDefaultState
+	}
+}
+
+// Function to get the button's
+// implementation
+func (e *ButtonImpl) buttonImpl() *ButtonImpl { return e }
+
 // Function to get the button's
 // background field from XML for
 // the given state
 func (e *ButtonImpl) GetButtonBkgField(s ButtonState) string {
-	if s == ButtonDefaultState {
-		return e.GetBkg().GetField()
-	} else if s == ButtonHoveredState {
+	switch s {
+	case ButtonHoveredState:
 		return e.HoveredBackground
-	} else {
+	case ButtonPressedState:
 		return e.PressedBackground
+	case ButtonFocusedState:
+		return e.FocusedBackground
+	case ButtonDisabledState:
+		return e.DisabledBackground
+	default:
+		return e.GetBkg().GetField()
 	}
 }
 
@@ -129,99 +330,218 @@ func (e *ButtonImpl) SetButtonBkg(state ButtonState, sprite *pixel.Sprite) {
 }
 
 // Function to call the press callback
-func (e *ButtonImpl) CallPressCallback() error {
-	if e.PressCallback != "" {
-		return Call(e.PressCallback, e)
+// (unless the button is disabled),
+// because of the given event (which
+// may be nil)
+func (e *ButtonImpl) CallPressCallback(ev Event) error {
+	if e.PressCallback != "" && e.Enabled {
+		return Call(e.PressCallback, e, ev)
 	}
 	return nil
 }
 
+// Function to get the names of the
+// callbacks the button calls
+func (e *ButtonImpl) GetCallbackNames() (names []string) {
+	for _, name := range []string{e.PressCallback, e.ReleaseCallback,
+		e.ClickCallback, e.DoubleClickCallback, e.LongPressCallback,
+		e.RightClickCallback, e.MiddleClickCallback} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// Function to call one of the button's
+// callbacks (if it's set), logging any
+// error it returns
+func callButtonCallback(e Button, name string, ev Event) {
+	if name == "" {
+		return
+	}
+	err := Call(name, e, ev)
+	if err != nil {
+		// could be better
+		log.Printf("Error from button callback: %+v", err)
+	}
+}
+
 // Function to determine whether
 // the element is initialised
 func (e *ButtonImpl) IsInitialised() bool {
-	return e.Impl.IsInitialised() &&
-		(e.HoveredBackground == "" || e.backgrounds[ButtonHoveredState] != nil) &&
-		(e.PressedBackground == "" || e.backgrounds[ButtonPressedState] != nil)
+	if !e.Impl.IsInitialised() {
+		return false
+	}
+	// Make sure the state backgrounds are loaded
+	for _, state := range buttonBkgStates {
+		if e.GetButtonBkgField(state) != "" && e.backgrounds[state] == nil {
+			return false
+		}
+	}
+	return true
 }
 
 // Function to initialise the element
-func (e *ButtonImpl) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
+func (e *ButtonImpl) Init(r Renderer) error {
 	// Initialise the element
-	err := e.Impl.Init(window, bounds)
+	err := e.Impl.Init(r)
 	if err != nil {
 		return err
 	}
 
 	// If the bounds are known
-	if bounds != nil {
+	if e.GetBounds() != nil {
 		// If the default background hasn't been set
 		if e.backgrounds[ButtonDefaultState] == nil {
 			e.backgrounds[ButtonDefaultState] = e.Impl.GetBkg().GetSprite()
 		}
-		// If the hovered background hasn't been made
-		if e.backgrounds[ButtonHoveredState] == nil {
-			// Load the background picture
-			picture, err := util.CreatePictureFromField(e.GetFS(), e.HoveredBackground)
-			if err != nil {
-				return err
-			}
-			if picture != nil {
-				// Create a sprite
-				e.backgrounds[ButtonHoveredState] = pixel.NewSprite(picture, picture.Bounds())
-			} else {
-				e.backgrounds[ButtonHoveredState] = e.backgrounds[ButtonDefaultState]
-			}
-		}
-		// If the pressed background hasn't been made
-		if e.backgrounds[ButtonPressedState] == nil {
-			// Load the background picture
-			picture, err := util.CreatePictureFromField(e.GetFS(), e.PressedBackground)
-			if err != nil {
-				return err
-			}
-			if picture != nil {
-				e.backgrounds[ButtonPressedState] = pixel.NewSprite(picture, picture.Bounds())
-			} else {
-				e.backgrounds[ButtonPressedState] = e.backgrounds[ButtonDefaultState]
+		// Iterate over the other states
+		for _, state := range buttonBkgStates {
+			// If the state's background hasn't been made
+			if e.backgrounds[state] == nil {
+				// Load the background picture
+				picture, err := util.CreatePictureFromField(
+					e.GetFS(), e.GetButtonBkgField(state))
+				if err != nil {
+					return err
+				}
+				if picture != nil {
+					// Create a sprite
+					e.backgrounds[state] = pixel.NewSprite(picture, picture.Bounds())
+				} else {
+					e.backgrounds[state] = e.backgrounds[ButtonDefaultState]
+				}
 			}
 		}
+		// Use the current state's background
+		// (e.g. if the button starts disabled)
+		e.SetButtonState(e.inputState())
 	}
 	return nil
 }
 
-// Function to handle a button's new event
-func ButtonNewEvent(e Button, window *pixelgl.Window) {
-	// Whether the button's state changed
-	stateChange := false
-	// If the mouse is actually in the window
-	if window.MouseInsideWindow() &&
-		e.GetCanvas().Bounds().Contains(window.MousePosition()) {
-		// If the mouse button is being pressed
-		if window.Pressed(pixelgl.MouseButtonLeft) {
-			stateChange = e.GetButtonState() != ButtonPressedState
-			e.SetButtonState(ButtonPressedState)
-
-			// If the state changed
-			if stateChange {
-				// Call the press callback
-				err := e.CallPressCallback()
-				if err != nil {
-					// could be better
-					log.Printf("Error from button callback: %+v", err)
-				}
+// Function to determine whether the
+// given key activates a focused button
+func isActivationKey(k Key) bool { return k == KeyEnter || k == KeySpace }
+
+// Function to handle a button's event.
+// Disabled buttons ignore their input.
+// A click only counts if it's pressed
+// and released on the button, without
+// the mouse leaving the button between
+func ButtonHandleEvent(e Button, ev Event) {
+	// Only handle events that reached the button
+	if ev.GetPhase() != EventTargetPhase {
+		return
+	}
+	b := e.buttonImpl()
+
+	// Whether the button was activated
+	// (so the press callback is called)
+	activated := false
+	switch ev.GetType() {
+	case EventMouseEnter:
+		b.hovered = true
+	case EventMouseLeave:
+		// Leaving the button cancels any presses
+		b.hovered = false
+		b.cancelPress()
+	case EventMouseDown:
+		mouseEv := ev.(*MouseEvent)
+		if e.IsEnabled() {
+			b.pressTimes[mouseEv.Button] = ev.GetTime()
+			if mouseEv.Button == MouseButtonLeft {
+				b.pressed = true
+				b.longPressed = false
 			}
-		} else {
-			stateChange = e.GetButtonState() != ButtonHoveredState
-			e.SetButtonState(ButtonHoveredState)
+			// The button used the event
+			ev.StopPropagation()
+		}
+	case EventMouseHold:
+		// If the left mouse button has been
+		// held long enough, it's a long press
+		start, ok := b.pressTimes[MouseButtonLeft]
+		if ok && ev.(*MouseEvent).Button == MouseButtonLeft &&
+			!b.longPressed && b.LongPressCallback != "" &&
+			ev.GetTime().Sub(start) >= b.LongPressTime {
+			b.longPressed = true
+			callButtonCallback(e, b.LongPressCallback, ev)
+		}
+	case EventMouseUp:
+		mouseEv := ev.(*MouseEvent)
+		// Make sure the press started on the button
+		_, ok := b.pressTimes[mouseEv.Button]
+		if !ok {
+			break
+		}
+		delete(b.pressTimes, mouseEv.Button)
+
+		switch mouseEv.Button {
+		case MouseButtonLeft:
+			b.pressed = false
+			callButtonCallback(e, b.ReleaseCallback, ev)
+			// A long press isn't a click
+			if b.longPressed {
+				break
+			}
+			activated = true
+			callButtonCallback(e, b.ClickCallback, ev)
+			// If the last click was recent
+			// enough, it's a double click
+			if !b.lastClick.IsZero() &&
+				ev.GetTime().Sub(b.lastClick) <= b.DoubleClickTime {
+				b.lastClick = time.Time{}
+				callButtonCallback(e, b.DoubleClickCallback, ev)
+			} else {
+				b.lastClick = ev.GetTime()
+			}
+		case MouseButtonRight:
+			callButtonCallback(e, b.RightClickCallback, ev)
+		case MouseButtonMiddle:
+			callButtonCallback(e, b.MiddleClickCallback, ev)
+		}
+	case EventFocus:
+		b.focused = true
+	case EventBlur:
+		// Losing the focus cancels a key press
+		b.focused = false
+		if b.keyPressed {
+			b.keyPressed = false
+			b.pressed = false
+		}
+	// Enter and space press the
+	// button when it has the focus
+	case EventKeyDown:
+		if isActivationKey(ev.(*KeyEvent).Key) && e.IsEnabled() {
+			b.keyPressed = true
+			b.pressed = true
+			// The button used the event
+			ev.StopPropagation()
+		}
+	case EventKeyUp:
+		if isActivationKey(ev.(*KeyEvent).Key) && b.keyPressed {
+			b.keyPressed = false
+			b.pressed = false
+			callButtonCallback(e, b.ReleaseCallback, ev)
+			activated = true
 		}
-	} else {
-		stateChange = e.GetButtonState() != ButtonDefaultState
-		e.SetButtonState(ButtonDefaultState)
 	}
 
 	// If the state was changed
-	if stateChange {
-		// Redraw the whole UI
-		DrawUI(e, window)
+	if b.inputState() != e.GetButtonState() {
+		e.SetButtonState(b.inputState())
+		// The button needs drawing again
+		e.InvalidatePaint()
+	}
+
+	// If the button was activated
+	if activated {
+		// Call the press callback
+		err := e.CallPressCallback(ev)
+		if err != nil {
+			// could be better
+			log.Printf("Error from button callback: %+v", err)
+		}
 	}
 }
@@ -0,0 +1,377 @@
+package element_test
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/render/software"
+	"github.com/faiface/pixel"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// The names of the callbacks a test
+// button calls, which are the same
+// as the attributes they're set by
+var buttonCallbacks = []string{"press", "release", "click",
+	"double-click", "long-press", "right-click", "middle-click"}
+
+// Function to load and initialise a
+// 10px square image button with the
+// given attributes, whose callbacks
+// (see buttonCallbacks) add their
+// names to the returned log
+func loadButton(t *testing.T, attrs string) (element.Button, *[]string) {
+	t.Helper()
+	log := new([]string)
+	registry := element.NewRegistry(element.DefaultRegistry())
+	callbackAttrs := ""
+	for _, name := range buttonCallbacks {
+		name := name
+		registry.RegisterCallback(name, func(*element.CallbackContext) error {
+			*log = append(*log, name)
+			return nil
+		})
+		callbackAttrs += ` builtin:` + name + `-callback="` + name + `"`
+	}
+
+	design := `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+		<ImageButton builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"` +
+		callbackAttrs + ` ` + attrs + `/>
+	</LinearLayout>`
+	fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(design)}})
+	root, err := element.NewRoot(fs, nil, "design.xml", registry)
+	if err != nil {
+		t.Fatalf("error loading design: %v", err)
+	}
+	r := software.NewRenderer(pixel.R(0, 0, 100, 100))
+	bounds := r.Bounds()
+	err = element.InitUI(root.Element, r, &bounds)
+	if err != nil {
+		t.Fatalf("error initialising design: %v", err)
+	}
+	return child(root.Element, 0).(element.Button), log
+}
+
+// Function to make a mouse event for
+// the given button, the given time
+// after the start of the test
+func mouseEvent(typ element.EventType, button element.MouseButton,
+	after time.Duration) *element.MouseEvent {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &element.MouseEvent{
+		EventImpl: element.EventImpl{Type: typ, Time: start.Add(after)},
+		Button:    button,
+	}
+}
+
+// Function to make a key event
+func keyEvent(typ element.EventType, key element.Key) *element.KeyEvent {
+	return &element.KeyEvent{EventImpl: element.EventImpl{Type: typ}, Key: key}
+}
+
+// Function to make a focus event
+func focusEvent(typ element.EventType) *element.FocusEvent {
+	return &element.FocusEvent{EventImpl: element.EventImpl{Type: typ}}
+}
+
+func TestButtonStates(t *testing.T) {
+	left := element.MouseButtonLeft
+	steps := []struct {
+		name string
+		ev   element.Event
+		want element.ButtonState
+	}{
+		{"hovered", mouseEvent(element.EventMouseEnter, left, 0), element.ButtonHoveredState},
+		{"pressed", mouseEvent(element.EventMouseDown, left, 0), element.ButtonPressedState},
+		{"released", mouseEvent(element.EventMouseUp, left, 0), element.ButtonHoveredState},
+		{"left", mouseEvent(element.EventMouseLeave, left, 0), element.ButtonDefaultState},
+		{"focused", focusEvent(element.EventFocus), element.ButtonFocusedState},
+		{"pressed with a key", keyEvent(element.EventKeyDown, element.KeyEnter), element.ButtonPressedState},
+		{"released with a key", keyEvent(element.EventKeyUp, element.KeyEnter), element.ButtonFocusedState},
+		{"other key", keyEvent(element.EventKeyDown, element.KeyA), element.ButtonFocusedState},
+		// Being hovered over beats having the focus
+		{"hovered while focused", mouseEvent(element.EventMouseEnter, left, 0), element.ButtonHoveredState},
+		{"left while focused", mouseEvent(element.EventMouseLeave, left, 0), element.ButtonFocusedState},
+		{"blurred", focusEvent(element.EventBlur), element.ButtonDefaultState},
+	}
+	b, _ := loadButton(t, "")
+	if got := b.GetButtonState(); got != element.ButtonDefaultState {
+		t.Fatalf("got initial state '%s', want '%s'", got, element.ButtonDefaultState)
+	}
+	for _, step := range steps {
+		element.DispatchEvent(b, step.ev)
+		if got := b.GetButtonState(); got != step.want {
+			t.Errorf("%s: got state '%s', want '%s'", step.name, got, step.want)
+		}
+	}
+}
+
+func TestButtonBackgrounds(t *testing.T) {
+	b, _ := loadButton(t, `builtin:bkg-hovered="#00FF00" builtin:bkg-disabled="#0000FF"`)
+	def := b.GetButtonBkg(element.ButtonDefaultState)
+	if def == nil {
+		t.Fatalf("the default background wasn't loaded")
+	}
+
+	// States without a background of
+	// their own use the default one
+	for _, test := range []struct {
+		state element.ButtonState
+		own   bool
+	}{
+		{element.ButtonHoveredState, true},
+		{element.ButtonPressedState, false},
+		{element.ButtonFocusedState, false},
+		{element.ButtonDisabledState, true},
+	} {
+		bkg := b.GetButtonBkg(test.state)
+		if bkg == nil {
+			t.Errorf("the '%s' background wasn't loaded", test.state)
+		} else if got := bkg != def; got != test.own {
+			t.Errorf("'%s' has its own background: got %t, want %t", test.state, got, test.own)
+		}
+	}
+
+	// The button is drawn with
+	// its state's background
+	for _, enabled := range []bool{false, true} {
+		b.SetEnabled(enabled)
+		want := b.GetButtonBkg(b.GetButtonState())
+		if got := b.GetBkg().GetSprite(); got != want {
+			t.Errorf("the '%s' button isn't drawn with its background", b.GetButtonState())
+		}
+	}
+}
+
+func TestButtonDisabled(t *testing.T) {
+	left := element.MouseButtonLeft
+	// Input that would press and
+	// release the button
+	input := []element.Event{
+		mouseEvent(element.EventMouseEnter, left, 0),
+		mouseEvent(element.EventMouseDown, left, 0),
+		mouseEvent(element.EventMouseUp, left, 0),
+		focusEvent(element.EventFocus),
+		keyEvent(element.EventKeyDown, element.KeySpace),
+		keyEvent(element.EventKeyUp, element.KeySpace),
+	}
+
+	tests := []struct {
+		name string
+		// The button's attributes, and whether
+		// it's disabled after it's loaded
+		attrs   string
+		disable bool
+	}{
+		{"from XML", `builtin:enabled="false"`, false},
+		{"with SetEnabled", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, log := loadButton(t, test.attrs)
+			if test.disable {
+				b.SetEnabled(false)
+			}
+			if b.IsEnabled() || b.IsFocusable() {
+				t.Fatalf("got enabled %t and focusable %t, want neither", b.IsEnabled(), b.IsFocusable())
+			}
+
+			// The input is ignored
+			for _, ev := range input {
+				element.DispatchEvent(b, ev)
+				if got := b.GetButtonState(); got != element.ButtonDisabledState {
+					t.Errorf("got state '%s' after %s, want '%s'", got, ev.GetType(),
+						element.ButtonDisabledState)
+				}
+				if ev.IsPropagationStopped() {
+					t.Errorf("the disabled button used %s", ev.GetType())
+				}
+			}
+			// And so is the press callback
+			err := b.CallPressCallback(nil)
+			if err != nil || len(*log) != 0 {
+				t.Errorf("got callbacks %v (and error %v), want none", *log, err)
+			}
+
+			// Until the button is enabled (the mouse
+			// is still over it, which beats the focus)
+			b.SetEnabled(true)
+			if got := b.GetButtonState(); got != element.ButtonHoveredState {
+				t.Errorf("got state '%s' once enabled, want '%s'", got, element.ButtonHoveredState)
+			}
+			err = b.CallPressCallback(nil)
+			if err != nil || len(*log) != 1 || (*log)[0] != "press" {
+				t.Errorf("got callbacks %v (and error %v) once enabled, want [press]", *log, err)
+			}
+		})
+	}
+}
+
+func TestButtonHandleEvent(t *testing.T) {
+	left := element.MouseButtonLeft
+	right := element.MouseButtonRight
+	middle := element.MouseButtonMiddle
+	enter := element.EventMouseEnter
+	leave := element.EventMouseLeave
+	down := element.EventMouseDown
+	hold := element.EventMouseHold
+	up := element.EventMouseUp
+	ms := time.Millisecond
+	tests := []struct {
+		name  string
+		attrs string
+		input []element.Event
+		// The callbacks that were called,
+		// and the button's state after
+		want      []string
+		wantState element.ButtonState
+	}{
+		{
+			name:      "click",
+			input:     []element.Event{mouseEvent(down, left, 0), mouseEvent(up, left, 100*ms)},
+			want:      []string{"release", "click", "press"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name: "dragged out",
+			input: []element.Event{mouseEvent(enter, left, 0), mouseEvent(down, left, 0),
+				mouseEvent(leave, left, 50*ms), mouseEvent(enter, left, 100*ms),
+				mouseEvent(up, left, 150*ms)},
+			want:      nil,
+			wantState: element.ButtonHoveredState,
+		},
+		{
+			name:      "pressed somewhere else",
+			input:     []element.Event{mouseEvent(enter, left, 0), mouseEvent(up, left, 0)},
+			want:      nil,
+			wantState: element.ButtonHoveredState,
+		},
+		{
+			name: "double click",
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(up, left, 100*ms),
+				mouseEvent(down, left, 300*ms), mouseEvent(up, left, 400*ms)},
+			want: []string{"release", "click", "press",
+				"release", "click", "double-click", "press"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			// A click can't be part of two double clicks
+			name: "triple click",
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(up, left, 100*ms),
+				mouseEvent(down, left, 200*ms), mouseEvent(up, left, 300*ms),
+				mouseEvent(down, left, 400*ms), mouseEvent(up, left, 500*ms)},
+			want: []string{"release", "click", "press",
+				"release", "click", "double-click", "press",
+				"release", "click", "press"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name: "too slow for a double click",
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(up, left, 100*ms),
+				mouseEvent(down, left, 500*ms), mouseEvent(up, left, 700*ms)},
+			want:      []string{"release", "click", "press", "release", "click", "press"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name:  "longer double click time",
+			attrs: `builtin:double-click-time="1s"`,
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(up, left, 100*ms),
+				mouseEvent(down, left, 500*ms), mouseEvent(up, left, 700*ms)},
+			want: []string{"release", "click", "press",
+				"release", "click", "double-click", "press"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name: "held, but not long enough",
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(hold, left, 500*ms),
+				mouseEvent(up, left, 700*ms)},
+			want:      []string{"release", "click", "press"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			// The long press is only called once,
+			// and the press isn't a click
+			name: "long press",
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(hold, left, 500*ms),
+				mouseEvent(hold, left, 800*ms), mouseEvent(hold, left, 900*ms),
+				mouseEvent(up, left, 1000*ms)},
+			want:      []string{"long-press", "release"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name:  "shorter long press time",
+			attrs: `builtin:long-press-time="200ms"`,
+			input: []element.Event{mouseEvent(down, left, 0), mouseEvent(hold, left, 300*ms),
+				mouseEvent(up, left, 400*ms)},
+			want:      []string{"long-press", "release"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name:      "right click",
+			input:     []element.Event{mouseEvent(down, right, 0), mouseEvent(up, right, 100*ms)},
+			want:      []string{"right-click"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name:      "middle click",
+			input:     []element.Event{mouseEvent(down, middle, 0), mouseEvent(up, middle, 100*ms)},
+			want:      []string{"middle-click"},
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			// Only the left button presses the button
+			name:      "right button held",
+			input:     []element.Event{mouseEvent(enter, right, 0), mouseEvent(down, right, 0)},
+			want:      nil,
+			wantState: element.ButtonHoveredState,
+		},
+		{
+			name: "pressed with the keyboard",
+			input: []element.Event{focusEvent(element.EventFocus),
+				keyEvent(element.EventKeyDown, element.KeyEnter),
+				keyEvent(element.EventKeyUp, element.KeyEnter)},
+			want:      []string{"release", "press"},
+			wantState: element.ButtonFocusedState,
+		},
+		{
+			name: "blurred while pressed with the keyboard",
+			input: []element.Event{focusEvent(element.EventFocus),
+				keyEvent(element.EventKeyDown, element.KeySpace), focusEvent(element.EventBlur),
+				keyEvent(element.EventKeyUp, element.KeySpace)},
+			want:      nil,
+			wantState: element.ButtonDefaultState,
+		},
+		{
+			name:  "disabled",
+			attrs: `builtin:enabled="false"`,
+			input: []element.Event{mouseEvent(enter, left, 0),
+				mouseEvent(down, left, 0), mouseEvent(hold, left, 900*ms), mouseEvent(up, left, 1000*ms),
+				mouseEvent(down, right, 0), mouseEvent(up, right, 100*ms),
+				mouseEvent(down, middle, 0), mouseEvent(up, middle, 100*ms)},
+			want:      nil,
+			wantState: element.ButtonDisabledState,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, log := loadButton(t, test.attrs)
+			for _, ev := range test.input {
+				element.DispatchEvent(b, ev)
+			}
+			if len(*log) != len(test.want) {
+				t.Errorf("got callbacks %v, want %v", *log, test.want)
+			} else {
+				for i := range *log {
+					if (*log)[i] != test.want[i] {
+						t.Errorf("got callbacks %v, want %v", *log, test.want)
+						break
+					}
+				}
+			}
+			if got := b.GetButtonState(); got != test.wantState {
+				t.Errorf("got state '%s', want '%s'", got, test.wantState)
+			}
+		})
+	}
+}
@@ -2,38 +2,256 @@ package element
 
 import (
 	"errors"
+	"github.com/faiface/pixel"
+	"sync"
 )
 
+// Interface type for whatever is
+// hosting an element tree (e.g.
+// a ui.Design)
+type Host interface {
+	// Function to get the root
+	// element of the tree
+	Root() Element
+	// Function to find an element in
+	// the tree with the given ID
+	FindElementByID(id string) Element
+}
+
+// Type for the context a
+// callback is called with
+type CallbackContext struct {
+	// The element calling the callback
+	Element Element
+	// The event that caused the
+	// callback (or nil)
+	Event Event
+	// The mouse's position when
+	// the event happened
+	Position pixel.Vec
+	// The modifier keys held down
+	// when the event happened
+	Mods Modifiers
+	// The host of the element's tree
+	// (or nil, if it isn't known)
+	Host Host
+}
+
+// Function to create the context for
+// calling a callback from the given
+// element, because of the given event
+// (which may be nil)
+func NewCallbackContext(e Element, ev Event) *CallbackContext {
+	ctx := &CallbackContext{Element: e, Event: ev}
+	if ev == nil {
+		return ctx
+	}
+	// Use what the dispatcher knew about
+	// the input, then the event itself
+	b := ev.base()
+	ctx.Position, ctx.Mods, ctx.Host = b.mousePos, b.mods, b.host
+	switch ev := ev.(type) {
+	case *MouseEvent:
+		ctx.Position, ctx.Mods = ev.Position, ev.Mods
+	case *ScrollEvent:
+		ctx.Position, ctx.Mods = ev.Position, ev.Mods
+	case *KeyEvent:
+		ctx.Mods = ev.Mods
+	}
+	return ctx
+}
+
 // Type for a callback
-type Callback func(Element) error
+type Callback func(ctx *CallbackContext) error
+
+// Type for a callback that takes the
+// arguments it's given in the XML (e.g.
+// "navigate('settings', 2)"). The arguments
+// are strings, ints, float64s, bools and
+// the referenced Elements
+type CallbackWithArgs func(ctx *CallbackContext, args ...interface{}) error
+
+// Type for a set of callbacks,
+// with the key being the
+// callback's name. It's safe to use
+// from multiple goroutines
+type CallbackRegistry struct {
+	sync.RWMutex
 
-// Type for a callback map
-type callbackMap map[string]Callback
+	// The callbacks
+	callbacks map[string]CallbackWithArgs
+	// The registry to fall back on
+	// for unknown callbacks (or nil)
+	parent *CallbackRegistry
+}
+
+// Function to create a callback registry.
+// Unknown callbacks are looked up in the
+// parent registry (unless it's nil)
+func NewCallbackRegistry(parent *CallbackRegistry) *CallbackRegistry {
+	return &CallbackRegistry{
+		callbacks: make(map[string]CallbackWithArgs),
+		parent:    parent,
+	}
+}
+
+// Function to add a callback
+// to the registry. Calling it with
+// any arguments is an error
+func (r *CallbackRegistry) Register(name string, c Callback) {
+	r.Lock()
+	defer r.Unlock()
+	r.callbacks[name] = func(ctx *CallbackContext, args ...interface{}) error {
+		if len(args) > 0 {
+			return errors.New("callback '" + name + "' doesn't take arguments")
+		}
+		return c(ctx)
+	}
+}
+
+// Function to add a callback that
+// takes arguments to the registry
+func (r *CallbackRegistry) RegisterWithArgs(name string, c CallbackWithArgs) {
+	r.Lock()
+	defer r.Unlock()
+	r.callbacks[name] = c
+}
 
-// Map of callbacks, with the
-// key being the callback's name
-var callbacks callbackMap
+// Function to get the callback with
+// the given name from the registry
+// (or its parent)
+func (r *CallbackRegistry) Lookup(name string) (CallbackWithArgs, bool) {
+	for ; r != nil; r = r.parent {
+		r.RLock()
+		callback, ok := r.callbacks[name]
+		r.RUnlock()
+		if ok {
+			return callback, true
+		}
+	}
+	return nil, false
+}
 
-// Function to initialise the callback map
-func init() {
-	// Create the element types map
-	callbacks = make(callbackMap, 0)
+// Function to call a callback, given
+// its invocation from an attribute (e.g.
+// "navigate('settings', 2)"), which is
+// parsed with ParseCallbackExpr
+func (r *CallbackRegistry) Call(expr string, ctx *CallbackContext) error {
+	// Parse the invocation
+	c, err := ParseCallbackExpr(expr)
+	if err != nil {
+		return err
+	}
+	// Try to get the callback
+	callback, ok := r.Lookup(c.Name)
+	if !ok {
+		return errors.New("unknown callback '" + c.Name + "'")
+	}
+	// Get the arguments
+	args, err := c.resolveArgs(ctx.Element, ctx.Host)
+	if err != nil {
+		return err
+	}
+	return callback(ctx, args...)
 }
 
-// Function to register a new callback
+// Function to get the global callbacks
+// (the default registry's callbacks)
+func GlobalCallbacks() *CallbackRegistry { return defaultRegistry.callbacks }
+
+// Function to register a new global callback
 func RegisterCallback(name string, c Callback) {
 	// Add the callback
-	callbacks[name] = c
+	defaultRegistry.RegisterCallback(name, c)
 }
 
-// Function to call a callback
-func Call(name string, e Element) error {
-	// Try to get the callback
-	callback, ok := callbacks[name]
-	// If it was found, call it
-	if ok {
-		return callback(e)
-	} else {
-		return errors.New("unknown callback '" + name + "'")
+// Function to register a new global
+// callback that takes arguments
+func RegisterCallbackWithArgs(name string, c CallbackWithArgs) {
+	defaultRegistry.RegisterCallbackWithArgs(name, c)
+}
+
+// Function to call a callback from the given
+// element (given its invocation), because of
+// the given event (which may be nil). The
+// callback is looked up in the registry the
+// event was dispatched with, or the callbacks
+// of the registry the element was created with
+func Call(expr string, e Element, ev Event) error {
+	registry := e.GetRegistry().Callbacks()
+	if ev != nil && ev.base().callbacks != nil {
+		registry = ev.base().callbacks
+	}
+	return registry.Call(expr, NewCallbackContext(e, ev))
+}
+
+// Interface type for an element
+// that calls callbacks
+type CallbackCaller interface {
+	// Function to get the invocations of
+	// the callbacks the element calls
+	GetCallbackNames() []string
+}
+
+// Type for an error when an
+// element uses a callback that
+// hasn't been registered
+type UnknownCallbackError struct {
+	Element  Element
+	Callback string
+}
+
+// Function to return the error string
+func (e UnknownCallbackError) Error() string {
+	return "unknown callback '" + e.Callback +
+		"' (used by XML element '" + FullName(e.Element, ".", false) + "')"
+}
+
+// Function to check every callback
+// used by the given element tree can be
+// parsed, is in the given registry (if
+// not, an UnknownCallbackError is returned)
+// and only references elements in the tree.
+// When validating (see ValidateDesign), the
+// problems are collected instead
+func ValidateCallbacks(root Element, r *CallbackRegistry) error {
+	// Recursive function to check an element
+	var check func(Element) error
+	check = func(e Element) error {
+		caller, ok := e.(CallbackCaller)
+		if ok {
+			for _, expr := range caller.GetCallbackNames() {
+				c, err := ParseCallbackExpr(expr)
+				if err != nil {
+					err = ReportLoadError(err, e)
+					if err != nil {
+						return WrapLoadError(err, e)
+					}
+					continue
+				}
+				_, ok = r.Lookup(c.Name)
+				if !ok {
+					err = ReportLoadError(UnknownCallbackError{e, c.Name}, e)
+				} else {
+					_, err = c.resolveArgs(e, nil)
+					err = ReportLoadError(err, e)
+				}
+				if err != nil {
+					return WrapLoadError(err, e)
+				}
+			}
+		}
+		// If it's a layout, check the children
+		layout, ok := e.(Layout)
+		if ok {
+			for i := 0; i < layout.NumChildren(); i++ {
+				err := check(layout.GetChild(i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	}
+	return check(root)
 }
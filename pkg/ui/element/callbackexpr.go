@@ -0,0 +1,243 @@
+package element
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Type for a reference to an element
+// (by its ID) in a callback's arguments,
+// which is resolved when it's called
+type ElementRef string
+
+// Type for a callback invocation from
+// an attribute (e.g. "navigate('settings', 2)")
+type CallbackExpr struct {
+	// The callback's name
+	Name string
+	// The literal arguments, which are
+	// strings, ints, float64s, bools and
+	// ElementRefs
+	Args []interface{}
+}
+
+// Type for an error when a callback
+// invocation can't be parsed
+type CallbackExprError struct {
+	// The invocation
+	Expr string
+	// Where in the invocation
+	// the error is
+	Offset int
+	// What's wrong
+	Reason string
+}
+
+// Function to return the error string
+func (e CallbackExprError) Error() string {
+	return "invalid callback '" + e.Expr + "' at offset " +
+		strconv.Itoa(e.Offset) + ": " + e.Reason
+}
+
+// Function to determine whether the given
+// rune can be part of a callback's name
+// or an element ID
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) ||
+		r == '_' || r == '-' || r == '.' || r == ':'
+}
+
+// Function to parse a callback invocation,
+// which is either just the callback's name
+// or the name followed by a list of comma
+// separated arguments in brackets. The
+// arguments are quoted strings, numbers,
+// true or false, or otherwise element IDs
+func ParseCallbackExpr(expr string) (c CallbackExpr, err error) {
+	// The invocation as runes, and
+	// the position in it
+	s := []rune(expr)
+	i := 0
+	// Function to create an error
+	fail := func(reason string) error {
+		return CallbackExprError{expr, i, reason}
+	}
+	// Function to skip whitespace
+	skipSpace := func() {
+		for i < len(s) && unicode.IsSpace(s[i]) {
+			i++
+		}
+	}
+	// Function to read a name (or ID)
+	readName := func() string {
+		start := i
+		for i < len(s) && isNameRune(s[i]) {
+			i++
+		}
+		return string(s[start:i])
+	}
+
+	// Read the callback's name
+	skipSpace()
+	c.Name = readName()
+	if c.Name == "" {
+		return c, fail("expected the callback's name")
+	}
+	skipSpace()
+	// If there aren't any arguments
+	if i == len(s) {
+		return c, nil
+	}
+	if s[i] != '(' {
+		return c, fail("expected '('")
+	}
+	i++
+
+	// Read the arguments
+	c.Args = make([]interface{}, 0)
+	skipSpace()
+	for i < len(s) && s[i] != ')' {
+		// If there was an argument before
+		// this one, there needs to be a comma
+		if len(c.Args) > 0 {
+			if s[i] != ',' {
+				return c, fail("expected ',' or ')'")
+			}
+			i++
+			skipSpace()
+		}
+		if i == len(s) {
+			break
+		}
+
+		switch r := s[i]; {
+		// A string
+		case r == '\'' || r == '"':
+			var str strings.Builder
+			i++
+			for i < len(s) && s[i] != r {
+				// Escaped characters are added as-is
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				str.WriteRune(s[i])
+				i++
+			}
+			if i == len(s) {
+				return c, fail("unterminated string")
+			}
+			i++
+			c.Args = append(c.Args, str.String())
+
+		// A number
+		case unicode.IsDigit(r) || r == '-' || r == '+' || r == '.':
+			start := i
+			for i < len(s) && (isNameRune(s[i]) || s[i] == '+') {
+				i++
+			}
+			num := string(s[start:i])
+			intVal, err := strconv.Atoi(num)
+			if err == nil {
+				c.Args = append(c.Args, intVal)
+				break
+			}
+			floatVal, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				i = start
+				return c, fail("invalid number '" + num + "'")
+			}
+			c.Args = append(c.Args, floatVal)
+
+		// A bool or an element ID
+		case isNameRune(r):
+			name := readName()
+			switch name {
+			case "true":
+				c.Args = append(c.Args, true)
+			case "false":
+				c.Args = append(c.Args, false)
+			default:
+				c.Args = append(c.Args, ElementRef(name))
+			}
+
+		default:
+			return c, fail("unexpected '" + string(r) + "'")
+		}
+		skipSpace()
+	}
+	if i == len(s) {
+		return c, fail("expected ')'")
+	}
+	i++
+
+	// There shouldn't be anything after the arguments
+	skipSpace()
+	if i != len(s) {
+		return c, fail("unexpected '" + string(s[i]) + "' after the arguments")
+	}
+	return c, nil
+}
+
+// Function to get the arguments to call
+// the callback with from the given element,
+// resolving any element references
+func (c CallbackExpr) resolveArgs(e Element, host Host) ([]interface{}, error) {
+	args := make([]interface{}, len(c.Args))
+	for i, arg := range c.Args {
+		ref, ok := arg.(ElementRef)
+		if !ok {
+			args[i] = arg
+			continue
+		}
+		// Find the referenced element
+		var refElem Element
+		if host != nil {
+			refElem = host.FindElementByID(string(ref))
+		} else {
+			refElem = FindElementByID(treeRoot(e), string(ref))
+		}
+		if refElem == nil {
+			return nil, NewNoElemError(e, string(ref), "callback")
+		}
+		args[i] = refElem
+	}
+	return args, nil
+}
+
+// Function to get the root of the
+// tree the given element is in
+func treeRoot(e Element) Element {
+	for e.GetParent() != nil {
+		e = e.GetParent()
+	}
+	return e
+}
+
+// Recursive function to search for an
+// element in the given tree with the
+// given ID. Returns nil if none do
+func FindElementByID(e Element, id string) Element {
+	// If the element is a match
+	if e.GetID() != nil && *e.GetID() == id {
+		return e
+	}
+	// Try to convert it to a layout
+	layout, ok := e.(Layout)
+	if ok {
+		// Do a shallow search
+		child := layout.GetChildByID(id)
+		if child != nil {
+			return child
+		}
+		// Otherwise iterate over the layout's children
+		for i := 0; i < layout.NumChildren(); i++ {
+			// Search the child for the matching element
+			child = FindElementByID(layout.GetChild(i), id)
+			if child != nil {
+				return child
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package element_test
+
+import (
+	"errors"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"reflect"
+	"testing"
+)
+
+func TestParseCallbackExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		want element.CallbackExpr
+	}{
+		{"save", element.CallbackExpr{Name: "save"}},
+		{"  save  ", element.CallbackExpr{Name: "save"}},
+		{"save()", element.CallbackExpr{Name: "save", Args: []interface{}{}}},
+		{"ui.save-all_2", element.CallbackExpr{Name: "ui.save-all_2"}},
+		{"navigate('settings', 2)",
+			element.CallbackExpr{Name: "navigate", Args: []interface{}{"settings", 2}}},
+		{`say("it's", 'a \'quote\'')`,
+			element.CallbackExpr{Name: "say", Args: []interface{}{"it's", "a 'quote'"}}},
+		{"move(-1, +2, 0.5, 1e3, .25)",
+			element.CallbackExpr{Name: "move", Args: []interface{}{-1, 2, 0.5, 1000.0, 0.25}}},
+		{"toggle(true,false)",
+			element.CallbackExpr{Name: "toggle", Args: []interface{}{true, false}}},
+		{"focus( input , 'input' )",
+			element.CallbackExpr{Name: "focus", Args: []interface{}{element.ElementRef("input"), "input"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			got, err := element.ParseCallbackExpr(test.expr)
+			if err != nil {
+				t.Fatalf("error parsing callback: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseCallbackExprError(t *testing.T) {
+	tests := []struct {
+		expr   string
+		offset int
+		reason string
+	}{
+		{"", 0, "expected the callback's name"},
+		{"(1)", 0, "expected the callback's name"},
+		{"save now", 5, "expected '('"},
+		{"save(", 5, "expected ')'"},
+		{"save(1 2)", 7, "expected ',' or ')'"},
+		{"save(1,", 7, "expected ')'"},
+		{"say('hi)", 8, "unterminated string"},
+		{"move(1.2.3)", 5, "invalid number '1.2.3'"},
+		{"move(1x)", 5, "invalid number '1x'"},
+		{"save(@)", 5, "unexpected '@'"},
+		{"save() now", 7, "unexpected 'n' after the arguments"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := element.ParseCallbackExpr(test.expr)
+			var exprErr element.CallbackExprError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("got error %v, want a CallbackExprError", err)
+			}
+			if exprErr.Offset != test.offset || exprErr.Reason != test.reason {
+				t.Errorf("got error at offset %d (%s), want offset %d (%s)",
+					exprErr.Offset, exprErr.Reason, test.offset, test.reason)
+			}
+		})
+	}
+}
+
+func TestCallbackRegistryCall(t *testing.T) {
+	root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">
+		<Image builtin:id="image" builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>
+	</LinearLayout>`)
+	image := child(root, 0)
+
+	// The arguments the callback was called with
+	var gotArgs []interface{}
+	parent := element.NewCallbackRegistry(nil)
+	parent.RegisterWithArgs("record", func(ctx *element.CallbackContext, args ...interface{}) error {
+		gotArgs = args
+		return nil
+	})
+	r := element.NewCallbackRegistry(parent)
+	r.Register("plain", func(*element.CallbackContext) error { return nil })
+
+	tests := []struct {
+		name string
+		expr string
+		args []interface{}
+		err  bool
+	}{
+		{"no arguments", "plain", nil, false},
+		{"arguments to a plain callback", "plain(1)", nil, true},
+		{"from the parent", "record('a', 1, true)", []interface{}{"a", 1, true}, false},
+		{"element reference", "record(image)", []interface{}{image}, false},
+		{"unknown element", "record(missing)", nil, true},
+		{"unknown callback", "missing", nil, true},
+		{"invalid invocation", "record(", nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotArgs = nil
+			err := r.Call(test.expr, element.NewCallbackContext(root, nil))
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want one: %t", err, test.err)
+			}
+			if len(gotArgs) != len(test.args) {
+				t.Fatalf("got arguments %v, want %v", gotArgs, test.args)
+			}
+			for i := range gotArgs {
+				if gotArgs[i] != test.args[i] {
+					t.Errorf("got arguments %v, want %v", gotArgs, test.args)
+				}
+			}
+		})
+	}
+}
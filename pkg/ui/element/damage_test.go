@@ -0,0 +1,107 @@
+package element
+
+import (
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+	"image/color"
+	"testing"
+)
+
+// Function to get a rectangle
+// that's 10px square at (x, y)
+func square(x, y float64) pixel.Rect { return pixel.R(x, y, x+10, y+10) }
+
+func TestAddDamage(t *testing.T) {
+	// Nine separate squares, one more
+	// than the regions that are kept
+	var squares []pixel.Rect
+	for i := 0; i <= maxDamageRegions; i++ {
+		squares = append(squares, square(float64(i)*20, 0))
+	}
+
+	tests := []struct {
+		name   string
+		damage []pixel.Rect
+		r      pixel.Rect
+		want   []pixel.Rect
+	}{
+		{"first region", nil, square(0, 0), []pixel.Rect{square(0, 0)}},
+		{"separate region", []pixel.Rect{square(0, 0)}, square(20, 0),
+			[]pixel.Rect{square(0, 0), square(20, 0)}},
+		{"overlapping region", []pixel.Rect{square(0, 0)}, square(5, 0),
+			[]pixel.Rect{square(0, 0), square(5, 0)}},
+		{"already damaged", []pixel.Rect{pixel.R(0, 0, 50, 50)}, square(10, 10),
+			[]pixel.Rect{pixel.R(0, 0, 50, 50)}},
+		{"same region", []pixel.Rect{square(0, 0)}, square(0, 0),
+			[]pixel.Rect{square(0, 0)}},
+		{"covers regions", []pixel.Rect{square(0, 0), square(60, 0), square(20, 20)},
+			pixel.R(0, 0, 40, 40), []pixel.Rect{square(60, 0), pixel.R(0, 0, 40, 40)}},
+		{"as many regions as kept", squares[:maxDamageRegions-1], squares[maxDamageRegions-1],
+			squares[:maxDamageRegions]},
+		{"too many regions", squares[:maxDamageRegions], squares[maxDamageRegions],
+			[]pixel.Rect{pixel.R(0, 0, float64(maxDamageRegions)*20+10, 10)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The given damage isn't changed
+			damage := append([]pixel.Rect{}, test.damage...)
+			got := addDamage(damage, test.r)
+			if len(got) != len(test.want) {
+				t.Fatalf("got damage %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got damage %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// Type for a surface that only
+// has bounds, for elements that
+// aren't actually drawn
+type testSurface struct {
+	bounds pixel.Rect
+}
+
+func (s *testSurface) Bounds() pixel.Rect                                    { return s.bounds }
+func (s *testSurface) SetBounds(bounds pixel.Rect)                           { s.bounds = bounds }
+func (s *testSurface) SetClip(*pixel.Rect)                                   {}
+func (s *testSurface) Clear(color.Color)                                     {}
+func (s *testSurface) DrawSprite(*pixel.Sprite, pixel.Matrix)                {}
+func (s *testSurface) DrawText(string, font.Face, color.Color, pixel.Matrix) {}
+func (s *testSurface) DrawSurface(Surface)                                   {}
+
+func TestInvalidateRect(t *testing.T) {
+	bounds := pixel.R(0, 0, 100, 100)
+	e := &Impl{canvas: &testSurface{bounds}}
+
+	// Rectangles are clipped to the canvas and
+	// rounded out to whole pixels, and ones
+	// outside the canvas are ignored
+	e.InvalidateRect(pixel.R(90.5, 10.2, 120, 19.8))
+	e.InvalidateRect(pixel.R(200, 200, 210, 210))
+	e.InvalidateRect(pixel.R(10, 10, 10, 20))
+	want := pixel.R(90, 10, 100, 20)
+	if got := e.GetDamage(); len(got) != 1 || got[0] != want {
+		t.Errorf("got damage %v, want [%v]", got, want)
+	}
+	if !e.NeedsPaint() {
+		t.Errorf("the element doesn't need drawing")
+	}
+
+	// Once the whole element is invalidated,
+	// the rectangles don't matter
+	e.InvalidatePaint()
+	e.InvalidateRect(square(0, 0))
+	if got := e.GetDamage(); len(got) != 1 || got[0] != bounds {
+		t.Errorf("got damage %v after InvalidatePaint, want [%v]", got, bounds)
+	}
+
+	// Drawing the element clears it
+	e.validatePaint()
+	if e.NeedsPaint() || len(e.GetDamage()) != 0 {
+		t.Errorf("got damage %v after drawing, want none", e.GetDamage())
+	}
+}
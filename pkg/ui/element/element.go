@@ -5,8 +5,7 @@ import (
 	"errors"
 	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/pixelgl"
-	"github.com/xlab/treeprint"
+	"math"
 	"net/http"
 )
 
@@ -19,6 +18,10 @@ type Element interface {
 	// Function to get the filesystem to use
 	// for opening files
 	GetFS() http.FileSystem
+	// Function to get the registry the
+	// element (and its children) was
+	// created with
+	GetRegistry() *Registry
 
 	// Function to get the element's XML name
 	GetName() xml.Name
@@ -87,6 +90,15 @@ type Element interface {
 	// gravity
 	GetGravity() util.Gravity
 
+	// Function to determine whether the
+	// element can have the keyboard focus
+	IsFocusable() bool
+	// Function to get the element's tab
+	// index, which orders it when moving
+	// the focus with tab. Elements with a
+	// negative tab index are skipped
+	GetTabIndex() int
+
 	// todo tint (or maybe foreground?)
 
 	// Function to get the element's
@@ -95,7 +107,7 @@ type Element interface {
 
 	// Function to get the element's
 	// canvas
-	GetCanvas() *pixelgl.Canvas
+	GetCanvas() Surface
 
 	// Function to unmarshal an XML element
 	// into a UI element. This function is
@@ -110,18 +122,72 @@ type Element interface {
 	// completely
 	Reset()
 
+	// Function to measure the element's
+	// width and height (as much as is
+	// currently possible), without
+	// needing a renderer
+	Measure(window pixel.Rect, bounds *pixel.Rect) error
+
+	// Function to arrange the element
+	// (and its children) within the given
+	// bounds, without needing a renderer.
+	// Measure should be called first
+	Arrange(window pixel.Rect, bounds *pixel.Rect) error
+
+	// Function to determine whether the
+	// element (and its children) have
+	// been arranged
+	IsArranged() bool
+
 	// Function to determine whether
 	// the element is initialised
 	IsInitialised() bool
 
-	// Function to initialise the element
-	// (load textures, create sprites, set
-	// sprite locations, etc.)
-	Init(window *pixelgl.Window, bounds *pixel.Rect) error
-
-	// Function that is called when there
-	// is a new event
-	NewEvent(*pixelgl.Window)
+	// Function to initialise the element's
+	// resources (create its canvas, create
+	// sprites, etc.). The element must have
+	// been arranged first
+	Init(r Renderer) error
+
+	// Function to mark the element's layout
+	// as invalid (e.g. because its content
+	// changed size), so it (and anything
+	// depending on it) is measured and
+	// arranged again by the next
+	// RelayoutUI. The element's ancestors
+	// are marked as needing layout too
+	InvalidateLayout()
+	// Function to determine whether the
+	// element (or one of its descendants)
+	// needs to be laid out again
+	NeedsLayout() bool
+
+	// Function to mark the element as
+	// needing to be drawn again. The
+	// element's ancestors are marked too,
+	// but only the area the element covers
+	// needs drawing again on their canvases
+	InvalidatePaint()
+	// Function to mark a rectangle of the
+	// element's canvas as needing to be
+	// drawn again (e.g. because a child
+	// there changed). The rest of the
+	// canvas is kept as it is
+	InvalidateRect(pixel.Rect)
+	// Function to determine whether the
+	// element (or one of its descendants)
+	// needs to be drawn again
+	NeedsPaint() bool
+	// Function to get the regions of the
+	// element's canvas that need to be
+	// drawn again (which is the whole
+	// canvas if InvalidatePaint was called)
+	GetDamage() []pixel.Rect
+
+	// Function to handle an event. This
+	// is called for each phase of the
+	// event (see DispatchEvent)
+	HandleEvent(Event)
 
 	// Function to draw the element
 	// to its canvas
@@ -137,6 +203,14 @@ type Impl struct {
 
 	// The filesystem to use
 	fs http.FileSystem
+	// The registry the element
+	// was created with
+	registry *Registry
+	// The XML file the element was
+	// loaded from (or nil), and the
+	// offset of its start tag in it
+	sourceFile   *sourceFile
+	sourceOffset int64
 
 	// The element's XML name
 	name xml.Name
@@ -172,10 +246,31 @@ type Impl struct {
 	Bkg Background
 
 	// The element's canvas
-	canvas *pixelgl.Canvas
+	canvas Surface
+
+	// Whether the element's layout
+	// has been invalidated
+	layoutInvalid bool
+	// Whether the layout of one of the
+	// element's descendants has been
+	// invalidated
+	childLayoutInvalid bool
+	// Whether the whole element
+	// needs to be drawn again
+	paintInvalid bool
+	// The regions of the element's
+	// canvas that need to be drawn
+	// again
+	damage []pixel.Rect
 
 	// The element's gravity
 	Gravity util.Gravity `uixml:"http://github.com/bhollier/ui/api/schema gravity,optional"`
+
+	// Whether the element can have
+	// the keyboard focus
+	Focusable bool `uixml:"http://github.com/bhollier/ui/api/schema focusable,optional"`
+	// The element's tab index
+	TabIndex int `uixml:"http://github.com/bhollier/ui/api/schema tab-index,optional"`
 }
 
 // Function to create an element
@@ -189,12 +284,21 @@ func NewElement(fs http.FileSystem, name xml.Name, parent Layout) Impl {
 	}
 
 	// If the parent was actually given,
-	// set the namespace as the parents.
-	// Otherwise make an empty array
+	// set the namespace and registry as
+	// the parents. Otherwise make an empty
+	// array and use the default registry
 	if parent != nil {
 		e.namespaces = parent.GetNamespaces()
+		e.registry = parent.GetRegistry()
+		// The element is probably in
+		// the same file as its parent
+		holder, ok := parent.(sourceHolder)
+		if ok {
+			e.sourceFile, _ = holder.getSource()
+		}
 	} else {
 		e.namespaces = make([]string, 0)
+		e.registry = defaultRegistry
 	}
 
 	// If the name has a namespace, then add it
@@ -212,6 +316,24 @@ func (e *Impl) GetParent() Layout { return e.parent }
 // for opening files
 func (e *Impl) GetFS() http.FileSystem { return e.fs }
 
+// Function to get the registry the
+// element was created with
+func (e *Impl) GetRegistry() *Registry { return e.registry }
+
+// Function to set the registry the
+// element was created with
+func (e *Impl) setRegistry(r *Registry) { e.registry = r }
+
+// Function to get the element's XML file
+// and the offset of its start tag
+func (e *Impl) getSource() (*sourceFile, int64) { return e.sourceFile, e.sourceOffset }
+
+// Function to set the element's XML file
+// and the offset of its start tag
+func (e *Impl) setSource(file *sourceFile, offset int64) {
+	e.sourceFile, e.sourceOffset = file, offset
+}
+
 // Function to get the element's name
 func (e *Impl) GetName() xml.Name { return e.name }
 
@@ -306,13 +428,21 @@ func (e *Impl) GetPadding() util.AbsoluteQuantity { return e.Padding }
 // gravity
 func (e *Impl) GetGravity() util.Gravity { return e.Gravity }
 
+// Function to determine whether the
+// element can have the keyboard focus
+func (e *Impl) IsFocusable() bool { return e.Focusable }
+
+// Function to get the element's
+// tab index
+func (e *Impl) GetTabIndex() int { return e.TabIndex }
+
 // Function to get the element's
 // background
 func (e *Impl) GetBkg() Image { return &e.Bkg }
 
 // Function to get the element's
 // canvas
-func (e *Impl) GetCanvas() *pixelgl.Canvas { return e.canvas }
+func (e *Impl) GetCanvas() Surface { return e.canvas }
 
 // Function to unmarshal an XML element into
 // an element. SetAttrs should've been called
@@ -342,16 +472,25 @@ func (e *Impl) Reset() {
 	// Reset the width and height
 	e.width = nil
 	e.height = nil
+	// The element will need drawing again
+	e.InvalidatePaint()
+}
+
+// Function to determine whether the
+// element has been arranged by whether
+// its position is set (not nil)
+func (e *Impl) IsArranged() bool {
+	return e.GetMin() != nil &&
+		e.GetMax() != nil
 }
 
 // Function to determine whether the
 // element has been initialised by
-// whether its width, height, position
-// and canvas are set (not nil) and if
+// whether it's been arranged, its
+// canvas is set (not nil) and if
 // it's background is initialised
 func (e *Impl) IsInitialised() bool {
-	return e.GetMin() != nil &&
-		e.GetMax() != nil &&
+	return e.IsArranged() &&
 		e.GetCanvas() != nil &&
 		e.GetCanvas().Bounds() == *e.GetBounds() &&
 		e.Bkg.IsInitialised()
@@ -359,7 +498,7 @@ func (e *Impl) IsInitialised() bool {
 
 // Function to calculate an element's
 // width
-func CalculateWidth(parent Element, window *pixelgl.Window,
+func CalculateWidth(parent Element, window pixel.Rect,
 	bounds *pixel.Rect, relWidth util.RelativeSize) (width *float64) {
 	// If the width is just in pixels
 	if relWidth.Unit == util.Pixels {
@@ -380,27 +519,22 @@ func CalculateWidth(parent Element, window *pixelgl.Window,
 		// If the width depends on the parent
 	} else if relWidth.MatchParent ||
 		relWidth.Unit == util.Percent {
-		// Go up the hierarchy until a parent
-		// is found that doesn't depend on it's
-		// child's width
-		next := parent
-		for next != nil && next.GetRelWidth().MatchContent {
-			next = next.GetParent()
-		}
-
-		// If the parent was found
-		if next != nil {
+		// Use the parent's width (if the parent
+		// matches its content, its width depends
+		// on the child's, which NewLayoutGraph
+		// reports as a LayoutCycleError)
+		if parent != nil {
 			if relWidth.MatchParent {
 				// Set the size as the parent's
-				width = next.GetActualWidth()
+				width = parent.GetActualWidth()
 
-			} else if next.GetActualWidth() != nil &&
+			} else if parent.GetActualWidth() != nil &&
 				relWidth.Unit == util.Percent {
-				newWidth := *next.GetActualWidth() * (float64(relWidth.Quantity) / 100)
+				newWidth := *parent.GetActualWidth() * (float64(relWidth.Quantity) / 100)
 				width = &newWidth
 			}
 		} else {
-			newWidth := window.Bounds().Max.X
+			newWidth := window.Max.X
 			if relWidth.Unit == util.Percent {
 				newWidth *= float64(relWidth.Quantity) / 100
 			}
@@ -412,7 +546,7 @@ func CalculateWidth(parent Element, window *pixelgl.Window,
 
 // Function to calculate an element's
 // height
-func CalculateHeight(parent Element, window *pixelgl.Window,
+func CalculateHeight(parent Element, window pixel.Rect,
 	bounds *pixel.Rect, relHeight util.RelativeSize) (height *float64) {
 	// If the height is just in pixels
 	if relHeight.Unit == util.Pixels {
@@ -432,27 +566,22 @@ func CalculateHeight(parent Element, window *pixelgl.Window,
 		// If the height depends on the parent
 	} else if relHeight.MatchParent ||
 		relHeight.Unit == util.Percent {
-		// Go up the hierarchy until a parent
-		// is found that doesn't depend on it's
-		// child's height
-		next := parent
-		for next != nil && next.GetRelHeight().MatchContent {
-			next = next.GetParent()
-		}
-
-		// If the parent was found
-		if next != nil {
+		// Use the parent's height (if the parent
+		// matches its content, its height depends
+		// on the child's, which NewLayoutGraph
+		// reports as a LayoutCycleError)
+		if parent != nil {
 			if relHeight.MatchParent {
 				// Set the size as the parent's
-				height = next.GetActualHeight()
+				height = parent.GetActualHeight()
 
-			} else if next.GetActualHeight() != nil &&
+			} else if parent.GetActualHeight() != nil &&
 				relHeight.Unit == util.Percent {
-				newHeight := *next.GetActualHeight() * (float64(relHeight.Quantity) / 100)
+				newHeight := *parent.GetActualHeight() * (float64(relHeight.Quantity) / 100)
 				height = &newHeight
 			}
 		} else {
-			newHeight := window.Bounds().Max.Y
+			newHeight := window.Max.Y
 			if relHeight.Unit == util.Percent {
 				newHeight *= float64(relHeight.Quantity) / 100
 			}
@@ -490,13 +619,28 @@ func CalculateMin(e Element, bounds *pixel.Rect, size pixel.Vec) (min *pixel.Vec
 	return
 }
 
-// Function to initialise an element's
-// position, width and height. Because
-// it doesn't know the element's actual
-// size, it won't set the width or height
-// if the relative width or height is
+// Function to check that an element
+// without any content (e.g. an image
+// without a source) doesn't want its
+// width or height to match its content
+func CheckNoContent(e Element) error {
+	if e.GetRelWidth().MatchContent {
+		return errors.New("invalid width attribute value 'match_content' on XML element '" +
+			FullName(e, ".", false) + "': no content to match")
+	} else if e.GetRelHeight().MatchContent {
+		return errors.New("invalid height attribute value 'match_content' on XML element '" +
+			FullName(e, ".", false) + "': no content to match")
+	}
+	return nil
+}
+
+// Function to measure an element's
+// width and height. Because it doesn't
+// know the element's actual size, it
+// won't set the width or height if the
+// relative width or height is
 // "match_content"
-func (e *Impl) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
+func (e *Impl) Measure(window pixel.Rect, bounds *pixel.Rect) error {
 	// If the width isn't known, try to calculate it
 	if e.width == nil {
 		e.width = CalculateWidth(e.GetParent(),
@@ -507,7 +651,13 @@ func (e *Impl) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 		e.height = CalculateHeight(e.GetParent(),
 			window, bounds, e.GetRelHeight())
 	}
+	return nil
+}
 
+// Function to arrange an element
+// within the given bounds, if its
+// width and height are known
+func (e *Impl) Arrange(_ pixel.Rect, bounds *pixel.Rect) error {
 	// If the bounds aren't known and
 	// the size is known
 	if e.GetBounds() == nil &&
@@ -526,15 +676,32 @@ func (e *Impl) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 			e.max = &max
 		}
 	}
+	return nil
+}
 
+// Function to initialise an element's
+// canvas and background. The canvas is
+// only created if it doesn't exist yet,
+// otherwise it's moved to the element's
+// bounds
+func (e *Impl) Init(r Renderer) error {
 	// If the bounds are known
 	if e.GetBounds() != nil {
 		// If the canvas hasn't been made
 		if e.GetCanvas() == nil {
 			// Create a canvas
-			e.canvas = pixelgl.NewCanvas(*e.GetBounds())
-		} else {
+			e.canvas = r.NewSurface(*e.GetBounds())
+			e.InvalidatePaint()
+		} else if e.GetCanvas().Bounds() != *e.GetBounds() {
+			// Where the canvas was needs
+			// drawing again on the parent
+			if e.parent != nil {
+				e.parent.InvalidateRect(e.GetCanvas().Bounds())
+			}
+			// Move the canvas (which may
+			// lose what was drawn on it)
 			e.GetCanvas().SetBounds(*e.GetBounds())
+			e.InvalidatePaint()
 		}
 	}
 
@@ -547,15 +714,173 @@ func (e *Impl) Init(window *pixelgl.Window, bounds *pixel.Rect) error {
 	return nil
 }
 
-// Function that is called when there
-// is a new event. This function does
-// nothing
-func (e *Impl) NewEvent(*pixelgl.Window) {}
+// Function to mark the element's
+// layout as invalid
+func (e *Impl) InvalidateLayout() {
+	e.layoutInvalid = true
+	// The element will need drawing again
+	e.InvalidatePaint()
+	// Tell the ancestors
+	p, ok := e.parent.(layoutValidity)
+	if ok {
+		p.invalidateChildLayout()
+	}
+}
+
+// Function to determine whether the
+// element (or one of its descendants)
+// needs to be laid out again
+func (e *Impl) NeedsLayout() bool {
+	return e.layoutInvalid || e.childLayoutInvalid
+}
+
+// Function to mark one of the
+// element's descendant's layout
+// as invalid
+func (e *Impl) invalidateChildLayout() {
+	e.childLayoutInvalid = true
+	// Tell the ancestors
+	p, ok := e.parent.(layoutValidity)
+	if ok {
+		p.invalidateChildLayout()
+	}
+}
+
+// Function to determine whether
+// the element's own layout has
+// been invalidated
+func (e *Impl) layoutInvalidated() bool { return e.layoutInvalid }
+
+// Function to mark the element's
+// (and its descendants') layout
+// as valid again
+func (e *Impl) validateLayout() {
+	e.layoutInvalid = false
+	e.childLayoutInvalid = false
+}
+
+// The most damaged regions an element
+// keeps track of before they're merged
+// into one
+const maxDamageRegions = 8
+
+// Function to mark the element
+// as needing to be drawn again
+func (e *Impl) InvalidatePaint() {
+	e.paintInvalid = true
+	e.damage = nil
+	// Tell the parent where the element
+	// is drawn (if it hasn't been drawn,
+	// the parent is told when the canvas
+	// is created)
+	if e.parent != nil && e.GetCanvas() != nil {
+		e.parent.InvalidateRect(e.GetCanvas().Bounds())
+	}
+}
+
+// Function to mark a rectangle of
+// the element's canvas as needing
+// to be drawn again
+func (e *Impl) InvalidateRect(r pixel.Rect) {
+	// If the whole element needs drawing,
+	// the parent already knows about it
+	if e.paintInvalid {
+		return
+	}
+	// If the element hasn't been
+	// drawn, everything needs drawing
+	if e.GetCanvas() == nil {
+		e.InvalidatePaint()
+		return
+	}
+
+	// Only the part of the rectangle
+	// within the element matters
+	r = r.Intersect(e.GetCanvas().Bounds())
+	if r.Area() == 0 {
+		return
+	}
+	// Round it out to whole pixels, as
+	// that's what is actually drawn
+	r = pixel.R(math.Floor(r.Min.X), math.Floor(r.Min.Y),
+		math.Ceil(r.Max.X), math.Ceil(r.Max.Y))
+	e.damage = addDamage(e.damage, r)
+
+	// Tell the parent
+	if e.parent != nil {
+		e.parent.InvalidateRect(r)
+	}
+}
+
+// Function to add a rectangle to a list
+// of damaged regions, skipping it if it's
+// already covered and merging the regions
+// if there are too many
+func addDamage(damage []pixel.Rect, r pixel.Rect) []pixel.Rect {
+	// If the rectangle is already damaged
+	for _, d := range damage {
+		if d.Intersect(r) == r {
+			return damage
+		}
+	}
+	// Drop any regions the rectangle covers
+	merged := make([]pixel.Rect, 0, len(damage)+1)
+	for _, d := range damage {
+		if r.Intersect(d) != d {
+			merged = append(merged, d)
+		}
+	}
+	merged = append(merged, r)
+
+	// If there are too many regions,
+	// merge them into one
+	if len(merged) > maxDamageRegions {
+		for _, d := range merged[1:] {
+			merged[0] = merged[0].Union(d)
+		}
+		merged = merged[:1]
+	}
+	return merged
+}
+
+// Function to determine whether
+// the element needs to be drawn
+// again
+func (e *Impl) NeedsPaint() bool { return e.paintInvalid || len(e.damage) > 0 }
+
+// Function to get the regions of the
+// element's canvas that need to be
+// drawn again
+func (e *Impl) GetDamage() []pixel.Rect {
+	if e.paintInvalid {
+		if e.GetCanvas() == nil {
+			return nil
+		}
+		return []pixel.Rect{e.GetCanvas().Bounds()}
+	}
+	return e.damage
+}
+
+// Function to mark the element
+// as drawn
+func (e *Impl) validatePaint() {
+	e.paintInvalid = false
+	e.damage = nil
+}
+
+// Function to handle an event.
+// This function does nothing
+func (e *Impl) HandleEvent(Event) {}
 
 // Function to draw the element.
 // This function should be called
-// first
+// first. The whole canvas is drawn
+// (layouts should use DrawLayout
+// instead, which only draws the
+// damaged regions)
 func (e *Impl) Draw() {
+	// The element is being drawn
+	e.validatePaint()
 	// Draw the background
 	DrawBkg(e, &e.Bkg)
 }
@@ -565,85 +890,99 @@ func (e *Impl) Draw() {
 // function is generally used by layouts,
 // or just anything that has child
 // element(s)
-func DrawCanvasOntoParent(child *pixelgl.Canvas, parent *pixelgl.Canvas) {
-	mat := pixel.IM
-	// Move it to where the canvas wants to be
-	mat = mat.Moved(child.Bounds().Center())
+func DrawCanvasOntoParent(child Surface, parent Surface) {
 	// Draw the child canvas onto the parent
-	child.Draw(parent, mat)
+	// (where the child canvas wants to be)
+	parent.DrawSurface(child)
 }
 
-// Function to reset and initialise the
-// entire UI element tree, by traversing
-// up the given element's parents
-func InitUI(e Element, window *pixelgl.Window, bounds *pixel.Rect) error {
+// Function to initialise the element
+// tree from the given root, returning
+// an error if any of the elements
+// couldn't be initialised
+func initUI(root Element, r Renderer) error {
+	// Initialise the element (and therefore all its children)
+	err := root.Init(r)
+	if err != nil {
+		return err
+	}
+
+	// If the element still isn't initialised, return an error
+	if !root.IsInitialised() {
+		return errors.New("element init failed. " +
+			"The following element(s) are still uninitialised: \n" +
+			ElementTree(root, func(e Element) bool {
+				return !e.IsInitialised()
+			}))
+	}
+	return nil
+}
+
+// Function to reset, arrange and
+// initialise the entire UI element
+// tree, by traversing up the given
+// element's parents
+func InitUI(e Element, r Renderer, bounds *pixel.Rect) error {
 	// While the element has a parent, go up the tree
 	for e.GetParent() != nil {
 		e = e.GetParent()
 	}
 
-	// Reset the element (and its children)
-	e.Reset()
-
-	// Keep initialising the element until it's all done
-	// or until the element has been initialised for the 1000th time
-	for i := 0; i < 1000 && !e.IsInitialised(); i++ {
-		// Initialise the element (and therefore all its children)
-		err := e.Init(window, bounds)
-		if err != nil {
-			return err
-		}
+	// Lay out the element tree
+	_, err := LayoutUI(e, r.Bounds(), bounds)
+	if err != nil {
+		return err
 	}
 
-	// If the element still isn't initialised (because the
-	// loop limit was reached) return an error
-	if !e.IsInitialised() {
-		// Make a tree of uninitialised elements
-		tree := treeprint.New()
-
-		// Recursive function to find uninitialised elements
-		var getUninitialisedElements func(treeprint.Tree, Element)
-		getUninitialisedElements = func(branch treeprint.Tree, e Element) {
-			// If the element isn't initialised
-			if !e.IsInitialised() {
-				elemName := Name(e, true)
-				// Try to convert to a layout
-				layout, ok := e.(Layout)
-				// If it is a layout, iterate over the children
-				if ok {
-					newBranch := branch.AddBranch(elemName)
-					for i := 0; i < layout.NumChildren(); i++ {
-						// Get the child element's uninitialised elements
-						getUninitialisedElements(newBranch, layout.GetChild(i))
-					}
-				} else {
-					branch.AddNode(elemName)
-				}
-			}
-		}
+	// Initialise it
+	return initUI(e, r)
+}
+
+// Function to lay out and initialise
+// only the parts of the UI element
+// tree (found by traversing up the
+// given element's parents) that have
+// been invalidated
+func UpdateUI(e Element, r Renderer, bounds *pixel.Rect) error {
+	// While the element has a parent, go up the tree
+	for e.GetParent() != nil {
+		e = e.GetParent()
+	}
 
-		// Call the function on root
-		getUninitialisedElements(tree, e)
+	// If nothing needs laying out, there's nothing to do
+	if !e.NeedsLayout() {
+		return nil
+	}
 
-		// Return an error with the uninitialised elements
-		return errors.New("infinite loop in element init detected. " +
-			"The following element(s) are still uninitialised: \n" + tree.String())
+	// Lay out the invalidated elements
+	_, err := RelayoutUI(e, r.Bounds(), bounds)
+	if err != nil {
+		return err
 	}
-	return nil
+
+	// Initialise them
+	return initUI(e, r)
 }
 
-// Function to draw the entire UI element
-// tree, by traversing up the given
-// element's parents
-func DrawUI(e Element, window *pixelgl.Window) {
+// Function to draw the parts of the UI
+// element tree (found by traversing up
+// the given element's parents) that
+// need drawing again, and display it.
+// Nothing is drawn if nothing changed
+func DrawUI(e Element, r Renderer) {
 	// While the element has a parent, go up the tree
 	for e.GetParent() != nil {
 		e = e.GetParent()
 	}
+	// If nothing needs drawing, there's nothing to do
+	if !e.NeedsPaint() {
+		return
+	}
+	// Get what changed before it's drawn
+	damage := e.GetDamage()
 	// Draw the element
 	e.Draw()
-	// Draw the element onto the window
-	DrawCanvasOntoParent(e.GetCanvas(), window.Canvas())
-	// Swap the window's buffers
-	window.SwapBuffers()
+	// Draw what changed onto the
+	// renderer and display it
+	r.Present(e.GetCanvas(), damage)
 }
@@ -1,5 +1,10 @@
 package element
 
+import (
+	"encoding/xml"
+	"strings"
+)
+
 // Type for an error when an
 // attribute's element ID doesn't
 // match an actual ID
@@ -26,4 +31,45 @@ func (e NoElemError) Error() string {
 		FullName(e.Element, ".", false) + "')"
 }
 
+// Type for an error when there
+// isn't an element type with an
+// XML element's name
+type UnknownElementError struct {
+	Name xml.Name
+	// Whether the element type might exist,
+	// but the name needs a namespace because
+	// the registry is strict
+	NeedsNamespace bool
+}
+
+// Function to return the error string
+func (e UnknownElementError) Error() string {
+	if e.NeedsNamespace {
+		return "element type '" + e.Name.Local +
+			"' has no namespace (which is required in strict mode)"
+	}
+	return "unknown element type '" + XMLNameToString(e.Name) + "'"
+}
+
+// Type for an error when an XML
+// element's name (without a namespace)
+// matches more than one element type
+type AmbiguousElementError struct {
+	Name xml.Name
+	// The names of the element types
+	// that match
+	Candidates []xml.Name
+}
+
+// Function to return the error string
+func (e AmbiguousElementError) Error() string {
+	candidates := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		candidates[i] = "'" + XMLNameToString(c) + "'"
+	}
+	return "ambiguous element type '" + XMLNameToString(e.Name) +
+		"' (could be " + strings.Join(candidates, ", ") +
+		"), use a namespace prefix to pick one"
+}
+
 // todo add more
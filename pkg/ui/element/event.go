@@ -0,0 +1,585 @@
+package element
+
+import (
+	"github.com/faiface/pixel"
+	"time"
+)
+
+// Type for the type of an event
+type EventType int
+
+const (
+	// A mouse button was pressed
+	EventMouseDown EventType = iota
+	// A mouse button was released
+	EventMouseUp
+	// A mouse button is still pressed
+	// (sent every time the input is
+	// polled until it's released)
+	EventMouseHold
+	// The mouse moved
+	EventMouseMove
+	// The mouse moved onto an element
+	EventMouseEnter
+	// The mouse moved off an element
+	EventMouseLeave
+	// The mouse wheel scrolled
+	EventScroll
+	// A key was pressed
+	EventKeyDown
+	// A key was released
+	EventKeyUp
+	// A character was typed
+	EventCharInput
+	// The window was resized
+	EventResize
+	// An element got the keyboard focus
+	EventFocus
+	// An element lost the keyboard focus
+	EventBlur
+)
+
+// Function to convert the event
+// type to a string
+func (t EventType) String() string {
+	switch t {
+	case EventMouseDown:
+		return "MouseDown"
+	case EventMouseUp:
+		return "MouseUp"
+	case EventMouseHold:
+		return "MouseHold"
+	case EventMouseMove:
+		return "MouseMove"
+	case EventMouseEnter:
+		return "MouseEnter"
+	case EventMouseLeave:
+		return "MouseLeave"
+	case EventScroll:
+		return "Scroll"
+	case EventKeyDown:
+		return "KeyDown"
+	case EventKeyUp:
+		return "KeyUp"
+	case EventCharInput:
+		return "CharInput"
+	case EventResize:
+		return "Resize"
+	case EventFocus:
+		return "Focus"
+	case EventBlur:
+		return "Blur"
+	default:
+		return "unknown"
+	}
+}
+
+// Function to determine whether events
+// of the type bubble back up the tree
+// after reaching their target
+func (t EventType) Bubbles() bool {
+	switch t {
+	case EventMouseEnter, EventMouseLeave, EventResize,
+		EventFocus, EventBlur:
+		return false
+	default:
+		return true
+	}
+}
+
+// Type for the phase of an event's
+// journey through the element tree
+type EventPhase int
+
+const (
+	// The event is going down from
+	// the root to the target's parent
+	EventCapturePhase EventPhase = iota
+	// The event is at its target
+	EventTargetPhase
+	// The event is going back up from
+	// the target's parent to the root
+	EventBubblePhase
+)
+
+// Type for the modifier keys held
+// down when an event happened
+type Modifiers int
+
+const (
+	// Either shift key
+	ModShift Modifiers = 1 << iota
+	// Either control key
+	ModControl
+	// Either alt key
+	ModAlt
+)
+
+// Interface type for an event. Every
+// event includes EventImpl
+type Event interface {
+	// Function to get the event's type
+	GetType() EventType
+	// Function to get when the
+	// event happened
+	GetTime() time.Time
+	// Function to get the element
+	// the event is for
+	GetTarget() Element
+	// Function to get the element
+	// currently handling the event
+	GetCurrentTarget() Element
+	// Function to get the event's
+	// current phase
+	GetPhase() EventPhase
+
+	// Function to stop the event going
+	// to any more elements
+	StopPropagation()
+	// Function to determine whether
+	// StopPropagation was called
+	IsPropagationStopped() bool
+
+	// Function to get the event's
+	// implementation, so it can be
+	// dispatched
+	base() *EventImpl
+}
+
+// Type for the implementation
+// of an event
+type EventImpl struct {
+	// The event's type
+	Type EventType
+	// When the event happened (if
+	// zero, it's set when the event
+	// is dispatched)
+	Time time.Time
+
+	// The element the event is for
+	target Element
+	// The element currently
+	// handling the event
+	currentTarget Element
+	// The event's current phase
+	phase EventPhase
+	// Whether StopPropagation
+	// was called
+	stopped bool
+
+	// What the dispatcher knew when it
+	// sent the event, for callbacks
+	// called because of it
+	callbacks *CallbackRegistry
+	host      Host
+	mousePos  pixel.Vec
+	mods      Modifiers
+}
+
+// Function to get the event's type
+func (e *EventImpl) GetType() EventType { return e.Type }
+
+// Function to get when the
+// event happened
+func (e *EventImpl) GetTime() time.Time { return e.Time }
+
+// Function to get the element
+// the event is for
+func (e *EventImpl) GetTarget() Element { return e.target }
+
+// Function to get the element
+// currently handling the event
+func (e *EventImpl) GetCurrentTarget() Element { return e.currentTarget }
+
+// Function to get the event's
+// current phase
+func (e *EventImpl) GetPhase() EventPhase { return e.phase }
+
+// Function to stop the event going
+// to any more elements
+func (e *EventImpl) StopPropagation() { e.stopped = true }
+
+// Function to determine whether
+// StopPropagation was called
+func (e *EventImpl) IsPropagationStopped() bool { return e.stopped }
+
+// Function to get the event's
+// implementation
+func (e *EventImpl) base() *EventImpl { return e }
+
+// Type for a mouse button event
+// (EventMouseDown, EventMouseHold
+// or EventMouseUp)
+// or a mouse movement event
+// (EventMouseMove, EventMouseEnter
+// or EventMouseLeave)
+type MouseEvent struct {
+	EventImpl
+	// The mouse's position
+	Position pixel.Vec
+	// The button that was pressed,
+	// held or released (for the
+	// mouse button events)
+	Button MouseButton
+	// The modifier keys held down
+	Mods Modifiers
+}
+
+// Type for a mouse wheel event
+// (EventScroll)
+type ScrollEvent struct {
+	EventImpl
+	// The mouse's position
+	Position pixel.Vec
+	// How much the wheel scrolled
+	Delta pixel.Vec
+	// The modifier keys held down
+	Mods Modifiers
+}
+
+// Type for a key event
+// (EventKeyDown or EventKeyUp)
+type KeyEvent struct {
+	EventImpl
+	// The key that was pressed
+	// or released
+	Key Key
+	// The modifier keys held down
+	Mods Modifiers
+}
+
+// Type for a typed character
+// event (EventCharInput)
+type CharEvent struct {
+	EventImpl
+	// The character that was typed
+	Char rune
+}
+
+// Type for a window resize
+// event (EventResize)
+type ResizeEvent struct {
+	EventImpl
+	// The window's new bounds
+	Bounds pixel.Rect
+}
+
+// Type for a focus event
+// (EventFocus or EventBlur)
+type FocusEvent struct {
+	EventImpl
+}
+
+// Function to dispatch an event to the
+// given target. The event goes down from
+// the root to the target (the capture
+// phase), to the target itself, then
+// back up to the root (the bubble phase,
+// if the event's type bubbles), until an
+// element stops its propagation
+func DispatchEvent(target Element, ev Event) {
+	b := ev.base()
+	b.target = target
+	b.stopped = false
+	if b.Time.IsZero() {
+		b.Time = time.Now()
+	}
+
+	// Get the target's ancestors,
+	// starting with its parent
+	var ancestors []Element
+	for p := target.GetParent(); p != nil; p = p.GetParent() {
+		ancestors = append(ancestors, p)
+	}
+
+	// Capture phase, from the root down
+	b.phase = EventCapturePhase
+	for i := len(ancestors) - 1; i >= 0 && !b.stopped; i-- {
+		b.currentTarget = ancestors[i]
+		ancestors[i].HandleEvent(ev)
+	}
+
+	// Target phase
+	if !b.stopped {
+		b.phase = EventTargetPhase
+		b.currentTarget = target
+		target.HandleEvent(ev)
+	}
+
+	// Bubble phase, from the parent up
+	if b.Type.Bubbles() {
+		b.phase = EventBubblePhase
+		for i := 0; i < len(ancestors) && !b.stopped; i++ {
+			b.currentTarget = ancestors[i]
+			ancestors[i].HandleEvent(ev)
+		}
+	}
+}
+
+// Type for something that turns the
+// state of an Input into events, and
+// dispatches them to an element tree
+type EventDispatcher struct {
+	// The root of the tree the
+	// events were last sent to
+	root Element
+
+	// The window's bounds
+	bounds pixel.Rect
+	// Whether the mouse was inside the window
+	mouseInside bool
+	// The mouse's position
+	mousePos pixel.Vec
+	// The mouse buttons that are pressed
+	mouseButtons map[MouseButton]bool
+	// The keys that are pressed
+	keys map[Key]bool
+
+	// The element the mouse is over
+	hovered Element
+	// The keyboard focus
+	focus FocusManager
+
+	// The host of the element tree
+	host Host
+	// The callbacks elements call
+	// because of the events
+	callbacks *CallbackRegistry
+}
+
+// Function to create an event dispatcher.
+// Callbacks called because of its events
+// are given the host, and are looked up
+// in the given registry (or the global
+// callbacks, if it's nil)
+func NewEventDispatcher(host Host, callbacks *CallbackRegistry) *EventDispatcher {
+	d := &EventDispatcher{
+		mouseButtons: make(map[MouseButton]bool),
+		keys:         make(map[Key]bool),
+		host:         host,
+		callbacks:    callbacks,
+	}
+	// Focus events (including from tab
+	// and Design.Focus) come from here too
+	d.focus.dispatch = d.dispatch
+	return d
+}
+
+// Function to get the element
+// the mouse is over (or nil)
+func (d *EventDispatcher) Hovered() Element { return d.hovered }
+
+// Function to get the dispatcher's
+// focus manager
+func (d *EventDispatcher) FocusManager() *FocusManager { return &d.focus }
+
+// Function to compare the window's input
+// to what it was when this function was
+// last called, and dispatch events for
+// anything that changed to the element
+// tree (found by traversing up the given
+// element's parents)
+func (d *EventDispatcher) Poll(e Element, window Window) {
+	// While the element has a parent, go up the tree
+	for e.GetParent() != nil {
+		e = e.GetParent()
+	}
+	// If the tree changed, forget the old one
+	if e != d.root {
+		if d.root != nil {
+			d.hovered = nil
+			d.focus.focused = nil
+		}
+		d.root = e
+	}
+
+	// If the window was resized
+	if window.Bounds() != d.bounds {
+		d.bounds = window.Bounds()
+		d.dispatch(d.root, &ResizeEvent{
+			EventImpl: EventImpl{Type: EventResize}, Bounds: d.bounds})
+	}
+
+	// Get the modifier keys
+	mods := d.pollKeys(window)
+
+	// Get the mouse's position, and
+	// the element it's over
+	inside := window.MouseInsideWindow()
+	pos := window.MousePosition()
+	var hit Element
+	if inside {
+		hit = ElementAt(d.root, pos)
+	}
+
+	// Update where the mouse is before the
+	// events are dispatched, so they see it
+	moved := !d.mouseInside || pos != d.mousePos
+	d.mouseInside = inside
+	d.mousePos = pos
+
+	// Update what the mouse is over (which
+	// can change without the mouse moving,
+	// e.g. if something scrolled)
+	d.updateHovered(hit, pos, mods)
+	// If the mouse moved
+	if hit != nil && moved {
+		d.dispatch(hit, &MouseEvent{
+			EventImpl: EventImpl{Type: EventMouseMove},
+			Position:  pos, Mods: mods})
+	}
+
+	// If nothing is under the mouse,
+	// send mouse events to the root
+	if hit == nil {
+		hit = d.root
+	}
+
+	// Iterate over the mouse buttons
+	for _, button := range []MouseButton{
+		MouseButtonLeft, MouseButtonRight, MouseButtonMiddle} {
+		pressed := window.MousePressed(button)
+		// If the button is still pressed
+		if pressed && d.mouseButtons[button] {
+			d.dispatch(hit, &MouseEvent{
+				EventImpl: EventImpl{Type: EventMouseHold},
+				Position:  pos, Button: button, Mods: mods})
+
+			// If the button was pressed or released
+		} else if pressed != d.mouseButtons[button] {
+			d.mouseButtons[button] = pressed
+			evType := EventMouseUp
+			if pressed {
+				evType = EventMouseDown
+				// Clicking moves the focus to
+				// what was clicked (or clears it)
+				if button == MouseButtonLeft {
+					d.focus.Focus(focusableAncestor(hit))
+				}
+			}
+			d.dispatch(hit, &MouseEvent{
+				EventImpl: EventImpl{Type: evType},
+				Position:  pos, Button: button, Mods: mods})
+		}
+	}
+
+	// If the mouse wheel scrolled
+	scroll := window.MouseScroll()
+	if inside && scroll != pixel.ZV {
+		d.dispatch(hit, &ScrollEvent{
+			EventImpl: EventImpl{Type: EventScroll},
+			Position:  pos, Delta: scroll, Mods: mods})
+	}
+}
+
+// Function to dispatch key and character
+// events (to the focused element, or the
+// root if nothing has the focus), returning
+// the modifier keys that are held down
+func (d *EventDispatcher) pollKeys(window Window) (mods Modifiers) {
+	// Iterate over the keys
+	for key := Key(0); key < numKeys; key++ {
+		pressed := window.KeyPressed(key)
+		if pressed != d.keys[key] {
+			d.keys[key] = pressed
+			evType := EventKeyUp
+			if pressed {
+				evType = EventKeyDown
+			}
+			// Get the modifiers with the key's new state
+			ev := &KeyEvent{
+				EventImpl: EventImpl{Type: evType},
+				Key:       key, Mods: d.modifiers()}
+			d.dispatch(d.keyTarget(), ev)
+
+			// If tab was pressed (and no
+			// element used it), move the focus
+			if pressed && key == KeyTab && !ev.IsPropagationStopped() {
+				d.focus.FocusNext(d.root, ev.Mods&ModShift != 0)
+			}
+		}
+	}
+
+	// Iterate over the typed characters
+	for _, char := range window.Typed() {
+		d.dispatch(d.keyTarget(), &CharEvent{
+			EventImpl: EventImpl{Type: EventCharInput}, Char: char})
+	}
+
+	return d.modifiers()
+}
+
+// Function to get the element key
+// events are sent to
+func (d *EventDispatcher) keyTarget() Element {
+	if d.focus.FocusedElement() != nil {
+		return d.focus.FocusedElement()
+	}
+	return d.root
+}
+
+// Function to get the modifier
+// keys that are held down
+func (d *EventDispatcher) modifiers() (mods Modifiers) {
+	if d.keys[KeyLeftShift] || d.keys[KeyRightShift] {
+		mods |= ModShift
+	}
+	if d.keys[KeyLeftControl] || d.keys[KeyRightControl] {
+		mods |= ModControl
+	}
+	if d.keys[KeyLeftAlt] || d.keys[KeyRightAlt] {
+		mods |= ModAlt
+	}
+	return
+}
+
+// Function to change the element the
+// mouse is over, sending EventMouseLeave
+// to the elements the mouse left (deepest
+// first) and EventMouseEnter to the
+// elements the mouse entered (deepest last)
+func (d *EventDispatcher) updateHovered(hit Element, pos pixel.Vec, mods Modifiers) {
+	if hit == d.hovered {
+		return
+	}
+
+	// Get the elements the mouse is over now
+	entered := make(map[Element]bool)
+	var enteredOrder []Element
+	for e := hit; e != nil; e = e.GetParent() {
+		entered[e] = true
+		enteredOrder = append(enteredOrder, e)
+	}
+
+	// Leave the elements the mouse isn't over anymore
+	left := make(map[Element]bool)
+	for e := d.hovered; e != nil; e = e.GetParent() {
+		left[e] = true
+		if !entered[e] {
+			d.dispatch(e, &MouseEvent{
+				EventImpl: EventImpl{Type: EventMouseLeave},
+				Position:  pos, Mods: mods})
+		}
+	}
+
+	// Enter the new ones, from the root down
+	for i := len(enteredOrder) - 1; i >= 0; i-- {
+		if !left[enteredOrder[i]] {
+			d.dispatch(enteredOrder[i], &MouseEvent{
+				EventImpl: EventImpl{Type: EventMouseEnter},
+				Position:  pos, Mods: mods})
+		}
+	}
+
+	d.hovered = hit
+}
+
+// Function to dispatch an event to
+// the given element, along with what
+// the dispatcher knows about the input
+func (d *EventDispatcher) dispatch(target Element, ev Event) {
+	b := ev.base()
+	b.callbacks, b.host = d.callbacks, d.host
+	b.mousePos, b.mods = d.mousePos, d.modifiers()
+	DispatchEvent(target, ev)
+}
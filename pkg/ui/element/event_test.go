@@ -0,0 +1,301 @@
+package element_test
+
+import (
+	"encoding/xml"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"testing"
+)
+
+// Type for an event handled
+// by an eventRecorder
+type handledEvent struct {
+	id    string
+	typ   element.EventType
+	phase element.EventPhase
+}
+
+// Type for an element (which can
+// have children) that records the
+// events it handles
+type eventRecorder struct {
+	testLayout
+	// The events handled by the tree
+	log *[]handledEvent
+	// The phase the element stops
+	// events in (or -1, if it doesn't)
+	stopIn element.EventPhase
+}
+
+// Function to add an event recorder
+// with the given ID and bounds to the
+// tree (or start one, if parent is nil)
+func newEventRecorder(id string, parent *eventRecorder, bounds pixel.Rect,
+	log *[]handledEvent) *eventRecorder {
+	e := &eventRecorder{log: log, stopIn: -1}
+	var p element.Layout
+	if parent != nil {
+		p = parent
+		parent.Children = append(parent.Children, e)
+	}
+	e.Impl = element.NewElement(nil, xml.Name{Local: "Recorder"}, p)
+	e.ID = id
+	e.SetMin(&bounds.Min)
+	e.SetMax(&bounds.Max)
+	return e
+}
+
+func (e *eventRecorder) HandleEvent(ev element.Event) {
+	id := *e.GetID()
+	if ev.GetCurrentTarget() != element.Element(e) {
+		id += " (not the current target)"
+	}
+	*e.log = append(*e.log, handledEvent{id, ev.GetType(), ev.GetPhase()})
+	if ev.GetPhase() == e.stopIn {
+		ev.StopPropagation()
+	}
+}
+
+// Function to check the events
+// that were handled
+func checkEvents(t *testing.T, name string, got, want []handledEvent) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got events %v, want %v", name, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s: got events %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+func TestDispatchEvent(t *testing.T) {
+	capture := element.EventCapturePhase
+	target := element.EventTargetPhase
+	bubble := element.EventBubblePhase
+	down := element.EventMouseDown
+	enter := element.EventMouseEnter
+	tests := []struct {
+		name string
+		typ  element.EventType
+		// The element that stops the
+		// event, and in which phase
+		stopper string
+		stopIn  element.EventPhase
+		want    []handledEvent
+	}{
+		{"capture, target then bubble", down, "", 0, []handledEvent{
+			{"root", down, capture}, {"middle", down, capture},
+			{"target", down, target},
+			{"middle", down, bubble}, {"root", down, bubble},
+		}},
+		{"doesn't bubble", enter, "", 0, []handledEvent{
+			{"root", enter, capture}, {"middle", enter, capture},
+			{"target", enter, target},
+		}},
+		{"stopped while capturing", down, "middle", capture, []handledEvent{
+			{"root", down, capture}, {"middle", down, capture},
+		}},
+		{"stopped at the target", down, "target", target, []handledEvent{
+			{"root", down, capture}, {"middle", down, capture},
+			{"target", down, target},
+		}},
+		{"stopped while bubbling", down, "middle", bubble, []handledEvent{
+			{"root", down, capture}, {"middle", down, capture},
+			{"target", down, target},
+			{"middle", down, bubble},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var log []handledEvent
+			bounds := pixel.R(0, 0, 100, 100)
+			root := newEventRecorder("root", nil, bounds, &log)
+			middle := newEventRecorder("middle", root, bounds, &log)
+			targetElem := newEventRecorder("target", middle, bounds, &log)
+			for _, e := range []*eventRecorder{root, middle, targetElem} {
+				if *e.GetID() == test.stopper {
+					e.stopIn = test.stopIn
+				}
+			}
+
+			ev := &element.MouseEvent{EventImpl: element.EventImpl{Type: test.typ}}
+			element.DispatchEvent(targetElem, ev)
+			checkEvents(t, "dispatching", log, test.want)
+			if ev.GetTarget() != element.Element(targetElem) {
+				t.Errorf("got target %v, want the target", ev.GetTarget())
+			}
+			if ev.GetTime().IsZero() {
+				t.Errorf("the event's time wasn't set")
+			}
+			if got := ev.IsPropagationStopped(); got != (test.stopper != "") {
+				t.Errorf("got propagation stopped %t, want %t", got, test.stopper != "")
+			}
+
+			// Dispatching the event again
+			// starts its propagation again
+			log = nil
+			element.DispatchEvent(targetElem, ev)
+			checkEvents(t, "dispatching again", log, test.want)
+		})
+	}
+}
+
+// Type for a window whose
+// input is set by the test
+type testWindow struct {
+	bounds  pixel.Rect
+	inside  bool
+	pos     pixel.Vec
+	scroll  pixel.Vec
+	buttons map[element.MouseButton]bool
+}
+
+func (w *testWindow) Bounds() pixel.Rect                      { return w.bounds }
+func (w *testWindow) NewSurface(pixel.Rect) element.Surface   { return nil }
+func (w *testWindow) Present(element.Surface, []pixel.Rect)   {}
+func (w *testWindow) MouseInsideWindow() bool                 { return w.inside }
+func (w *testWindow) MousePosition() pixel.Vec                { return w.pos }
+func (w *testWindow) MouseScroll() pixel.Vec                  { return w.scroll }
+func (w *testWindow) MousePressed(b element.MouseButton) bool { return w.buttons[b] }
+func (w *testWindow) KeyPressed(element.Key) bool             { return false }
+func (w *testWindow) Typed() string                           { return "" }
+
+func TestEventDispatcherMouse(t *testing.T) {
+	var log []handledEvent
+	root := newEventRecorder("root", nil, pixel.R(0, 0, 100, 100), &log)
+	a := newEventRecorder("a", root, pixel.R(0, 0, 50, 100), &log)
+	newEventRecorder("a1", a, pixel.R(0, 0, 50, 50), &log)
+	newEventRecorder("b", root, pixel.R(50, 0, 100, 100), &log)
+	// The window is wider than the root, so
+	// the mouse can be inside it over nothing
+	window := &testWindow{bounds: pixel.R(0, 0, 200, 100),
+		buttons: make(map[element.MouseButton]bool)}
+	d := element.NewEventDispatcher(nil, nil)
+
+	target := element.EventTargetPhase
+	steps := []struct {
+		name string
+		// The window's input
+		inside  bool
+		pos     pixel.Vec
+		pressed bool
+		// The events the elements were
+		// the targets of
+		want []handledEvent
+	}{
+		{"entered", true, pixel.V(25, 25), false, []handledEvent{
+			{"root", element.EventResize, target},
+			{"root", element.EventMouseEnter, target},
+			{"a", element.EventMouseEnter, target},
+			{"a1", element.EventMouseEnter, target},
+			{"a1", element.EventMouseMove, target},
+		}},
+		{"not moved", true, pixel.V(25, 25), false, nil},
+		{"moved to a sibling", true, pixel.V(75, 50), false, []handledEvent{
+			{"a1", element.EventMouseLeave, target},
+			{"a", element.EventMouseLeave, target},
+			{"b", element.EventMouseEnter, target},
+			{"b", element.EventMouseMove, target},
+		}},
+		{"pressed", true, pixel.V(75, 50), true, []handledEvent{
+			{"b", element.EventMouseDown, target},
+		}},
+		// Mouse events over nothing go to the root
+		{"moved off the root", true, pixel.V(150, 50), true, []handledEvent{
+			{"b", element.EventMouseLeave, target},
+			{"root", element.EventMouseLeave, target},
+			{"root", element.EventMouseHold, target},
+		}},
+		{"released off the root", true, pixel.V(150, 50), false, []handledEvent{
+			{"root", element.EventMouseUp, target},
+		}},
+		{"left the window", false, pixel.V(250, 50), false, nil},
+		{"entered again", true, pixel.V(25, 75), false, []handledEvent{
+			{"root", element.EventMouseEnter, target},
+			{"a", element.EventMouseEnter, target},
+			{"a", element.EventMouseMove, target},
+		}},
+	}
+	for _, step := range steps {
+		window.inside = step.inside
+		window.pos = step.pos
+		window.buttons[element.MouseButtonLeft] = step.pressed
+		log = nil
+		d.Poll(root, window)
+
+		// Only check where the events went
+		var got []handledEvent
+		for _, h := range log {
+			if h.phase == target {
+				got = append(got, h)
+			}
+		}
+		checkEvents(t, step.name, got, step.want)
+	}
+}
+
+func TestNestedScrolls(t *testing.T) {
+	// A 40px high scroll of a 20px high
+	// scroll (of 60px of images) and
+	// 60px of images
+	root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">
+		<Scroll builtin:width="match_parent" builtin:height="40px">
+			<LinearLayout builtin:width="match_parent" builtin:height="match_content">
+				<Scroll builtin:width="match_parent" builtin:height="20px">
+					<LinearLayout builtin:width="match_parent" builtin:height="match_content">
+						<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#FF0000"/>
+						<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#00FF00"/>
+						<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#0000FF"/>
+					</LinearLayout>
+				</Scroll>
+				<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#FF0000"/>
+				<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#00FF00"/>
+				<Image builtin:width="match_parent" builtin:height="20px" builtin:source="#0000FF"/>
+			</LinearLayout>
+		</Scroll>
+	</LinearLayout>`)
+	bounds := pixel.R(0, 0, 100, 100)
+	layoutBounds := bounds
+	_, err := element.LayoutUI(root, bounds, &layoutBounds)
+	if err != nil {
+		t.Fatalf("error laying out design: %v", err)
+	}
+	outer := child(root, 0, 0)
+	inner := child(root, 0, 0, 0, 0)
+
+	// The mouse is over the inner scroll
+	window := &testWindow{bounds: bounds, inside: true, pos: pixel.V(50, 90),
+		buttons: make(map[element.MouseButton]bool)}
+	d := element.NewEventDispatcher(nil, nil)
+	steps := []struct {
+		name  string
+		delta float64
+		// The tops of the scrolls' contents
+		outer, inner float64
+	}{
+		{"inner scrolled", -1, 100, 110},
+		{"inner scrolled to the end", -10, 100, 140},
+		// Only once the inner scroll can't
+		// scroll any more does the outer one
+		{"outer scrolled", -1, 110, 150},
+	}
+	for _, step := range steps {
+		window.scroll = pixel.V(0, step.delta)
+		d.Poll(root, window)
+		layoutBounds := bounds
+		_, err := element.RelayoutUI(root, bounds, &layoutBounds)
+		if err != nil {
+			t.Fatalf("%s: error laying out design: %v", step.name, err)
+		}
+		if got := elementRect(outer).Max.Y; got != step.outer {
+			t.Errorf("%s: got outer content top %v, want %v", step.name, got, step.outer)
+		}
+		if got := elementRect(inner).Max.Y; got != step.inner {
+			t.Errorf("%s: got inner content top %v, want %v", step.name, got, step.inner)
+		}
+	}
+}
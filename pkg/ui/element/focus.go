@@ -0,0 +1,150 @@
+package element
+
+import (
+	"github.com/faiface/pixel"
+	"sort"
+)
+
+// Type for something that keeps track
+// of which element has the keyboard
+// focus, which is where key events go
+type FocusManager struct {
+	// The element with the focus
+	// (or nil, if none do)
+	focused Element
+	// The function the focus events are
+	// dispatched with (or nil, to use
+	// DispatchEvent), so an EventDispatcher
+	// can add what it knows about the input
+	dispatch func(Element, Event)
+}
+
+// Function to get the element with
+// the keyboard focus (or nil)
+func (m *FocusManager) FocusedElement() Element { return m.focused }
+
+// Function to give the keyboard focus
+// to the given element, or to take it
+// away if nil. EventBlur is sent to the
+// element that had the focus, then
+// EventFocus to the given element
+func (m *FocusManager) Focus(e Element) {
+	if e == m.focused {
+		return
+	}
+	dispatch := m.dispatch
+	if dispatch == nil {
+		dispatch = DispatchEvent
+	}
+	prev := m.focused
+	m.focused = e
+	if prev != nil {
+		dispatch(prev, &FocusEvent{EventImpl{Type: EventBlur}})
+	}
+	if e != nil {
+		dispatch(e, &FocusEvent{EventImpl{Type: EventFocus}})
+	}
+}
+
+// Function to move the keyboard focus to
+// the next element in the given element
+// tree's tab order (or the previous one,
+// if reverse is true), wrapping around
+// at the end
+func (m *FocusManager) FocusNext(root Element, reverse bool) {
+	order := TabOrder(root)
+	if len(order) == 0 {
+		return
+	}
+
+	// Find the focused element in the order
+	current := -1
+	for i, e := range order {
+		if e == m.focused {
+			current = i
+			break
+		}
+	}
+
+	// Get the next element
+	var next int
+	if reverse {
+		if current <= 0 {
+			next = len(order) - 1
+		} else {
+			next = current - 1
+		}
+	} else {
+		next = (current + 1) % len(order)
+	}
+	m.Focus(order[next])
+}
+
+// Function to get the elements in the
+// given element tree that tab moves the
+// focus between, in order. Elements with
+// a positive tab index come first (lowest
+// first), then those with a tab index of
+// 0, otherwise they're in tree order.
+// Like HitTest, elements are clipped to
+// the bounds of their ancestors, so an
+// element that can't be seen (e.g. one
+// scrolled out of a scroll's view) can't
+// be focused
+func TabOrder(root Element) []Element {
+	var order []Element
+
+	// Recursive function to add the focusable
+	// elements in tree order, given the part
+	// of the tree the element can be seen in
+	var addElement func(Element, pixel.Rect)
+	addElement = func(e Element, clip pixel.Rect) {
+		// Only elements that have been arranged
+		// (and so can be seen) can be focused
+		if e.GetBounds() == nil {
+			return
+		}
+		// If none of the element can be seen,
+		// none of its children can either
+		clip = clip.Intersect(*e.GetBounds())
+		if clip.Area() == 0 {
+			return
+		}
+		if e.IsFocusable() && e.GetTabIndex() >= 0 {
+			order = append(order, e)
+		}
+		// If it's a layout, add the children
+		layout, ok := e.(Layout)
+		if ok {
+			for i := 0; i < layout.NumChildren(); i++ {
+				addElement(layout.GetChild(i), clip)
+			}
+		}
+	}
+	if root.GetBounds() != nil {
+		addElement(root, *root.GetBounds())
+	}
+
+	// Sort the elements by tab index (keeping
+	// the tree order for equal indices)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i].GetTabIndex(), order[j].GetTabIndex()
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+	return order
+}
+
+// Function to get the closest element
+// to the given element (including itself)
+// that can have the focus, or nil
+func focusableAncestor(e Element) Element {
+	for ; e != nil; e = e.GetParent() {
+		if e.IsFocusable() {
+			return e
+		}
+	}
+	return nil
+}
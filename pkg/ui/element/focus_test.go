@@ -0,0 +1,148 @@
+package element_test
+
+import (
+	_ "github.com/bhollier/ui/pkg/ui/builtin/button"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"testing"
+)
+
+// Function to get the IDs of
+// the given elements
+func elementIDs(elems []element.Element) (ids []string) {
+	for _, e := range elems {
+		if e.GetID() == nil {
+			ids = append(ids, "")
+		} else {
+			ids = append(ids, *e.GetID())
+		}
+	}
+	return
+}
+
+func TestTabOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		buttons string
+		want    []string
+	}{
+		{
+			name: "tree order",
+			buttons: `<ImageButton builtin:id="a" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<ImageButton builtin:id="b" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>`,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "positive tab indices first",
+			buttons: `<ImageButton builtin:id="a" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<ImageButton builtin:id="b" builtin:tab-index="2" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<ImageButton builtin:id="c" builtin:tab-index="1" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<ImageButton builtin:id="d" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>`,
+			want: []string{"c", "b", "a", "d"},
+		},
+		{
+			name: "unfocusable elements skipped",
+			buttons: `<ImageButton builtin:id="a" builtin:tab-index="-1" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<ImageButton builtin:id="b" builtin:focusable="false" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<ImageButton builtin:id="c" builtin:enabled="false" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				<Image builtin:id="d" builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>
+				<ImageButton builtin:id="e" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>`,
+			want: []string{"e"},
+		},
+		{
+			// The last button is scrolled out of view
+			name: "clipped by a scroll",
+			buttons: `<Scroll builtin:width="match_parent" builtin:height="20px">
+					<LinearLayout builtin:width="match_parent" builtin:height="match_content">
+						<ImageButton builtin:id="a" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+						<ImageButton builtin:id="b" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+						<ImageButton builtin:id="c" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+					</LinearLayout>
+				</Scroll>
+				<ImageButton builtin:id="d" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>`,
+			want: []string{"a", "b", "d"},
+		},
+		{
+			// The second button is outside its parent
+			name: "clipped by a layout",
+			buttons: `<LinearLayout builtin:width="10px" builtin:height="10px">
+					<ImageButton builtin:id="a" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+					<ImageButton builtin:id="b" builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+				</LinearLayout>`,
+			want: []string{"a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">`+
+				test.buttons+`</LinearLayout>`)
+
+			// Elements that haven't been arranged can't be focused
+			if order := element.TabOrder(root); len(order) != 0 {
+				t.Errorf("got tab order %v before layout, want none", elementIDs(order))
+			}
+
+			window := pixel.R(0, 0, 100, 100)
+			_, err := element.LayoutUI(root, window, &window)
+			if err != nil {
+				t.Fatalf("error laying out design: %v", err)
+			}
+			got := elementIDs(element.TabOrder(root))
+			if len(got) != len(test.want) {
+				t.Fatalf("got tab order %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got tab order %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// Type for a host of an element tree
+type testHost struct {
+	root element.Element
+}
+
+func (h *testHost) Root() element.Element { return h.root }
+
+func (h *testHost) FindElementByID(string) element.Element { return nil }
+
+// Type for an element that records
+// the hosts of the events sent to it
+type hostRecorder struct {
+	element.Impl
+	types []element.EventType
+	hosts []element.Host
+}
+
+func (e *hostRecorder) HandleEvent(ev element.Event) {
+	if ev.GetPhase() == element.EventTargetPhase {
+		e.types = append(e.types, ev.GetType())
+		e.hosts = append(e.hosts, element.NewCallbackContext(e, ev).Host)
+	}
+}
+
+func TestFocusEventsHaveHost(t *testing.T) {
+	e := &hostRecorder{}
+	host := &testHost{root: e}
+	d := element.NewEventDispatcher(host, nil)
+
+	// Focus then blur the element
+	d.FocusManager().Focus(e)
+	if d.FocusManager().FocusedElement() != element.Element(e) {
+		t.Errorf("the element wasn't focused")
+	}
+	d.FocusManager().Focus(nil)
+
+	want := []element.EventType{element.EventFocus, element.EventBlur}
+	if len(e.types) != len(want) || e.types[0] != want[0] || e.types[1] != want[1] {
+		t.Fatalf("got events %v, want %v", e.types, want)
+	}
+	for i, h := range e.hosts {
+		if h != element.Host(host) {
+			t.Errorf("%v event has host %v, want the dispatcher's", e.types[i], h)
+		}
+	}
+}
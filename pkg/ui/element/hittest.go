@@ -0,0 +1,49 @@
+package element
+
+import (
+	"github.com/faiface/pixel"
+)
+
+// Function to get the elements in the
+// given element tree at the given point,
+// from the top down (the order they're
+// drawn in reversed), so each element
+// comes before its ancestors. Elements
+// are clipped to the bounds of their
+// ancestors (as they're drawn onto their
+// parent's canvas), so a child scrolled
+// out of a scroll's view isn't found
+func HitTest(root Element, point pixel.Vec) []Element {
+	return hitTest(root, point, nil)
+}
+
+// Recursive function to add the elements
+// at the given point to the list of hits
+func hitTest(e Element, point pixel.Vec, hits []Element) []Element {
+	// If the point isn't in the element, it
+	// can't be in any of its children either
+	if e.GetBounds() == nil || !e.GetBounds().Contains(point) {
+		return hits
+	}
+	// If it's a layout, check the children
+	// (last to first, as later children
+	// are drawn on top)
+	layout, ok := e.(Layout)
+	if ok {
+		for i := layout.NumChildren() - 1; i >= 0; i-- {
+			hits = hitTest(layout.GetChild(i), point, hits)
+		}
+	}
+	return append(hits, e)
+}
+
+// Function to get the top element in
+// the given element tree at the given
+// point, or nil if there isn't one
+func ElementAt(root Element, point pixel.Vec) Element {
+	hits := HitTest(root, point)
+	if len(hits) == 0 {
+		return nil
+	}
+	return hits[0]
+}
@@ -0,0 +1,116 @@
+package element_test
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"testing"
+)
+
+func TestHitTest(t *testing.T) {
+	tests := []struct {
+		name   string
+		design string
+		point  pixel.Vec
+		// The IDs of the hit elements
+		want []string
+	}{
+		{
+			name: "nested",
+			design: `<LinearLayout ` + ns + ` builtin:id="root" builtin:width="match_parent" builtin:height="match_parent">
+				<LinearLayout builtin:id="layout" builtin:width="50px" builtin:height="50px">
+					<Image builtin:id="image" builtin:width="20px" builtin:height="20px" builtin:source="#FF0000"/>
+				</LinearLayout>
+			</LinearLayout>`,
+			point: pixel.V(10, 90),
+			want:  []string{"image", "layout", "root"},
+		},
+		{
+			name: "between children",
+			design: `<LinearLayout ` + ns + ` builtin:id="root" builtin:width="match_parent" builtin:height="match_parent">
+				<LinearLayout builtin:id="layout" builtin:width="50px" builtin:height="50px">
+					<Image builtin:id="image" builtin:width="20px" builtin:height="20px" builtin:source="#FF0000"/>
+				</LinearLayout>
+			</LinearLayout>`,
+			point: pixel.V(40, 60),
+			want:  []string{"layout", "root"},
+		},
+		{
+			name: "outside the root",
+			design: `<LinearLayout ` + ns + ` builtin:id="root" builtin:width="50px" builtin:height="50px">
+				<Image builtin:id="image" builtin:width="20px" builtin:height="20px" builtin:source="#FF0000"/>
+			</LinearLayout>`,
+			point: pixel.V(75, 25),
+			want:  nil,
+		},
+		{
+			// The later sibling is drawn on top
+			name: "overlapping siblings",
+			design: `<RelativeLayout ` + ns + ` builtin:id="root" builtin:width="match_parent" builtin:height="match_parent">
+				<Image builtin:id="under" builtin:width="30px" builtin:height="30px" builtin:right-of="parent"
+					builtin:source="#FF0000"/>
+				<Image builtin:id="over" builtin:width="20px" builtin:height="20px" builtin:right-of="parent"
+					builtin:source="#00FF00"/>
+			</RelativeLayout>`,
+			point: pixel.V(90, 90),
+			want:  []string{"over", "under", "root"},
+		},
+		{
+			name: "inside a scroll",
+			design: `<LinearLayout ` + ns + ` builtin:id="root" builtin:width="match_parent" builtin:height="match_parent">
+				<Scroll builtin:id="scroll" builtin:width="match_parent" builtin:height="40px">
+					<LinearLayout builtin:id="content" builtin:width="match_parent" builtin:height="match_content">
+						<Image builtin:id="top" builtin:width="match_parent" builtin:height="40px" builtin:source="#FF0000"/>
+						<Image builtin:id="bottom" builtin:width="match_parent" builtin:height="40px" builtin:source="#00FF00"/>
+					</LinearLayout>
+				</Scroll>
+			</LinearLayout>`,
+			point: pixel.V(50, 80),
+			want:  []string{"top", "content", "scroll", "root"},
+		},
+		{
+			// The bottom image is out of the
+			// scroll's view, so it isn't hit
+			name: "clipped by a scroll",
+			design: `<LinearLayout ` + ns + ` builtin:id="root" builtin:width="match_parent" builtin:height="match_parent">
+				<Scroll builtin:id="scroll" builtin:width="match_parent" builtin:height="40px">
+					<LinearLayout builtin:id="content" builtin:width="match_parent" builtin:height="match_content">
+						<Image builtin:id="top" builtin:width="match_parent" builtin:height="40px" builtin:source="#FF0000"/>
+						<Image builtin:id="bottom" builtin:width="match_parent" builtin:height="40px" builtin:source="#00FF00"/>
+					</LinearLayout>
+				</Scroll>
+			</LinearLayout>`,
+			point: pixel.V(50, 40),
+			want:  []string{"root"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := loadDesign(t, test.design)
+			window := pixel.R(0, 0, 100, 100)
+			_, err := element.LayoutUI(root, window, &window)
+			if err != nil {
+				t.Fatalf("error laying out design: %v", err)
+			}
+
+			got := elementIDs(element.HitTest(root, test.point))
+			if len(got) != len(test.want) {
+				t.Fatalf("got hits %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got hits %v, want %v", got, test.want)
+				}
+			}
+
+			// The element at the point is the top hit
+			e := element.ElementAt(root, test.point)
+			if len(test.want) == 0 {
+				if e != nil {
+					t.Errorf("got element %v at %v, want none", elementIDs([]element.Element{e}), test.point)
+				}
+			} else if e == nil || *e.GetID() != test.want[0] {
+				t.Errorf("got element %v at %v, want %s", e, test.point, test.want[0])
+			}
+		})
+	}
+}
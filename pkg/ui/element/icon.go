@@ -5,10 +5,10 @@ import (
 	"github.com/faiface/pixel"
 )
 
-// Function to initialise an image
+// Function to measure an image
 // as if it were an SVG icon. Should
 // be called if ImageIsSVG is true
-func initIcon(e Element, i Image) error {
+func measureIcon(e Element, i Image) error {
 	// If the SVG hasn't been loaded yet
 	if i.GetSVG() == nil {
 		// Load the svg
@@ -113,18 +113,34 @@ func initIcon(e Element, i Image) error {
 				e.SetActualWidth(&newHeight)
 			}
 		}
+	}
 
-		// If the image hasn't been created
-		// and the width and height are known
-		if i.GetSprite() == nil &&
-			e.GetActualWidth() != nil &&
-			e.GetActualHeight() != nil {
-			// Create a picture from the SVG
-			pic := util.CreatePictureFromSVG(i.GetSVG(), scale,
-				*e.GetActualWidth(), *e.GetActualHeight())
-			// Create a sprite with the picture and set it
-			i.SetSprite(pixel.NewSprite(pic, pic.Bounds()))
-		}
+	return nil
+}
+
+// Function to initialise an image
+// as if it were an SVG icon, by
+// creating its sprite. Should be
+// called if ImageIsSVG is true
+func initIcon(e Element, i Image) error {
+	// Get the scale
+	scale := i.GetScale()
+	if scale == util.ZeroScaleOption {
+		scale = util.DefaultScaleOption
+	}
+
+	// If the svg has been loaded, the image
+	// hasn't been created and the width and
+	// height are known
+	if i.GetSVG() != nil &&
+		i.GetSprite() == nil &&
+		e.GetActualWidth() != nil &&
+		e.GetActualHeight() != nil {
+		// Create a picture from the SVG
+		pic := util.CreatePictureFromSVG(i.GetSVG(), scale,
+			*e.GetActualWidth(), *e.GetActualHeight())
+		// Create a sprite with the picture and set it
+		i.SetSprite(pixel.NewSprite(pic, pic.Bounds()))
 	}
 
 	return nil
@@ -118,15 +118,18 @@ func (i *ImageImpl) IsInitialised() bool {
 		i.GetSprite() != nil
 }
 
-// Function to initialise an element's
-// image. Doesn't call element.Init
-func InitImage(e Element, i Image) error {
+// Function to measure an element's
+// image (loading it if needed) and set
+// the element's width and/or height if
+// they should match the image. Doesn't
+// call element.Measure
+func MeasureImage(e Element, i Image) error {
 	// todo set width should be a max
 
 	// If the image looks like an SVG
 	if i.IsSVG() {
-		// Defer to the svg init function
-		return initIcon(e, i)
+		// Defer to the svg measure function
+		return measureIcon(e, i)
 	}
 
 	// If the image hasn't been made yet
@@ -218,6 +221,19 @@ func InitImage(e Element, i Image) error {
 	return nil
 }
 
+// Function to initialise an element's
+// image, which creates the sprite of an
+// SVG image now the element's size is
+// known. Doesn't call element.Init
+func InitImage(e Element, i Image) error {
+	// If the image looks like an SVG
+	if i.IsSVG() {
+		// Defer to the svg init function
+		return initIcon(e, i)
+	}
+	return nil
+}
+
 // Function to draw an element's
 // image
 func DrawImage(e Element, i Image) {
@@ -229,7 +245,7 @@ func DrawImage(e Element, i Image) {
 		mat = mat.Moved(e.GetCanvas().Bounds().Center())
 		// todo use gravity
 		// Draw the sprite
-		i.GetSprite().Draw(e.GetCanvas(), mat)
+		e.GetCanvas().DrawSprite(i.GetSprite(), mat)
 
 	} else {
 		// If the sprite exists
@@ -257,7 +273,7 @@ func DrawImage(e Element, i Image) {
 						// Move the tile to the position
 						mat = mat.Moved(pixel.V(x, y))
 						// Draw it
-						i.GetSprite().Draw(e.GetCanvas(), mat)
+						e.GetCanvas().DrawSprite(i.GetSprite(), mat)
 					}
 				}
 			} else {
@@ -285,7 +301,7 @@ func DrawImage(e Element, i Image) {
 				mat = mat.Moved(e.GetCanvas().Bounds().Center())
 				// todo use gravity
 				// Draw the sprite
-				i.GetSprite().Draw(e.GetCanvas(), mat)
+				e.GetCanvas().DrawSprite(i.GetSprite(), mat)
 			}
 		}
 	}
@@ -2,8 +2,6 @@ package element
 
 import (
 	"encoding/xml"
-	"errors"
-	"github.com/faiface/pixel/pixelgl"
 	"net/http"
 )
 
@@ -85,21 +83,27 @@ func ChildrenUnmarshalXML(fs http.FileSystem, parent Layout, d *xml.Decoder,
 		// If this is the start of an element
 		case xml.StartElement:
 			// Create an element of the type
-			elem = New(fs, tt.Name, parent)
-			// If the element was created
-			if elem != nil {
-				// Decode the XML element into it
-				err = d.DecodeElement(elem, &tt)
+			elem, err = New(fs, tt.Name, parent)
+			if err != nil {
+				err = ReportChildLoadError(err, parent, d)
+				if err != nil {
+					return nil, WrapChildLoadError(err, parent, d)
+				}
+				// Skip the element
+				err = d.Skip()
 				if err != nil {
 					return nil, err
 				}
-				// Add it to the children array
-				children = append(children, elem)
-				elem = nil
-			} else {
-				return nil, errors.New("unknown element type '" +
-					XMLNameToString(tt.Name) + "'")
+				continue
 			}
+			RecordSourcePos(elem, d)
+			// Decode the XML element into it
+			err = d.DecodeElement(elem, &tt)
+			if err != nil {
+				return nil, WrapLoadError(err, elem)
+			}
+			// Add it to the children array
+			children = append(children, elem)
 			// If this is the end of the element
 		case xml.EndElement:
 			if tt == start.End() {
@@ -127,23 +131,81 @@ func ChildrenAreInitialised(e Layout) bool {
 	return true
 }
 
-// Function that is called when there
-// is a new event. This function only
-// calls NewEvent on the child elements
-func (e *LayoutImpl) NewEvent(window *pixelgl.Window) {
-	for _, child := range e.Children {
-		child.NewEvent(window)
+// Function to determine whether a layout's
+// children have been arranged. This
+// function doesn't call element.IsArranged
+func ChildrenAreArranged(e Layout) bool {
+	// Iterate over the children
+	for i := 0; i < e.NumChildren(); i++ {
+		// If the child hasn't been arranged
+		if !e.GetChild(i).IsArranged() {
+			return false
+		}
 	}
+	return true
 }
 
-// Function to draw a layout
-func DrawLayout(e Layout) {
+// Function to initialise a layout's
+// children. This function doesn't
+// call element.Init
+func InitChildren(e Layout, r Renderer) error {
 	// Iterate over the children
 	for i := 0; i < e.NumChildren(); i++ {
-		// Draw the child
-		e.GetChild(i).Draw()
-		// Draw the child onto the layout's canvas
-		DrawCanvasOntoParent(
-			e.GetChild(i).GetCanvas(), e.GetCanvas())
+		// Initialise the child
+		err := e.GetChild(i).Init(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Interface type for an element whose
+// paint state can be cleared, which is
+// implemented by Impl
+type paintValidity interface {
+	// Function to mark the element
+	// as drawn
+	validatePaint()
+}
+
+// Function to draw a layout (including
+// its background). Only the children
+// that need drawing are drawn again,
+// and only the layout's damaged regions
+// are drawn onto its canvas
+func DrawLayout(e Layout) {
+	// Get the regions that need drawing,
+	// before the children are drawn
+	damage := e.GetDamage()
+
+	// Draw the children that changed
+	for i := 0; i < e.NumChildren(); i++ {
+		if e.GetChild(i).NeedsPaint() {
+			e.GetChild(i).Draw()
+		}
+	}
+
+	// Iterate over the damaged regions
+	for i := range damage {
+		// Only draw within the region
+		e.GetCanvas().SetClip(&damage[i])
+		// Draw the background
+		DrawBkg(e, e.GetBkg())
+		// Draw the children in the region
+		// onto the layout's canvas
+		for j := 0; j < e.NumChildren(); j++ {
+			canvas := e.GetChild(j).GetCanvas()
+			if canvas != nil && canvas.Bounds().Intersect(damage[i]).Area() > 0 {
+				DrawCanvasOntoParent(canvas, e.GetCanvas())
+			}
+		}
+	}
+	e.GetCanvas().SetClip(nil)
+
+	// The layout has been drawn
+	p, ok := e.(paintValidity)
+	if ok {
+		p.validatePaint()
 	}
 }
@@ -0,0 +1,290 @@
+package element
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Type for a set of element types,
+// attribute types and callbacks that
+// designs can use. It's safe to use
+// from multiple goroutines
+type Registry struct {
+	sync.RWMutex
+
+	// The element types, with the key
+	// being the element's XML name
+	elementTypes elementTypesMap
+	// The attribute types, with the key
+	// being the attribute's (reflect) type
+	attributeTypes attributeTypesMap
+	// How the attribute types are described
+	// in the XML schema, with the key being
+	// the attribute's (reflect) type
+	attributeSchemas attributeSchemasMap
+	// The callbacks
+	callbacks *CallbackRegistry
+	// Whether element names need namespaces
+	// (or nil, if it's the parent's setting)
+	strict *bool
+
+	// The registry to fall back on for
+	// unknown types (or nil)
+	parent *Registry
+}
+
+// The default registry, which the
+// package level Register functions
+// add to
+var defaultRegistry = NewRegistry(nil)
+
+// Function to get the default registry,
+// which has the builtin types (and any
+// added with the package level Register
+// functions)
+func DefaultRegistry() *Registry { return defaultRegistry }
+
+// Function to create a registry. Anything
+// not found in it is looked up in the
+// parent registry (unless it's nil), so
+// a registry with the default registry as
+// its parent can add to it without
+// changing it
+func NewRegistry(parent *Registry) *Registry {
+	r := &Registry{
+		elementTypes:     make(elementTypesMap),
+		attributeTypes:   make(attributeTypesMap),
+		attributeSchemas: make(attributeSchemasMap),
+		parent:           parent,
+	}
+	if parent != nil {
+		r.callbacks = NewCallbackRegistry(parent.callbacks)
+	} else {
+		r.callbacks = NewCallbackRegistry(nil)
+	}
+	return r
+}
+
+// Function to get the registry's parent
+func (r *Registry) Parent() *Registry { return r.parent }
+
+// Function to get the registry's callbacks
+func (r *Registry) Callbacks() *CallbackRegistry { return r.callbacks }
+
+// Function to register a UI element type
+func (r *Registry) Register(name xml.Name, reflectType reflect.Type, factory Factory) {
+	r.Lock()
+	defer r.Unlock()
+	r.elementTypes[name] = elementType{
+		Name:        name,
+		ReflectType: reflectType,
+		Factory:     factory,
+	}
+}
+
+// Function to register an
+// attribute type
+func (r *Registry) RegisterAttrType(t reflect.Type, p AttrParser) {
+	r.Lock()
+	defer r.Unlock()
+	r.attributeTypes[t] = p
+}
+
+// Function to register how an attribute
+// type is described in the XML schema
+// (see WriteSchema). Attribute types
+// without one are described as strings
+func (r *Registry) RegisterAttrSchema(t reflect.Type, s AttrSchema) {
+	r.Lock()
+	defer r.Unlock()
+	r.attributeSchemas[t] = s
+}
+
+// Function to register a callback
+func (r *Registry) RegisterCallback(name string, c Callback) {
+	r.callbacks.Register(name, c)
+}
+
+// Function to register a callback
+// that takes arguments
+func (r *Registry) RegisterCallbackWithArgs(name string, c CallbackWithArgs) {
+	r.callbacks.RegisterWithArgs(name, c)
+}
+
+// Function to get the element type
+// with exactly the given name
+func (r *Registry) lookupElementType(name xml.Name) (elementType, bool) {
+	for ; r != nil; r = r.parent {
+		r.RLock()
+		elemType, ok := r.elementTypes[name]
+		r.RUnlock()
+		if ok {
+			return elemType, true
+		}
+	}
+	return elementType{}, false
+}
+
+// Function to get every element type in the
+// registry (and its parents), with the key
+// being the element's XML name
+func (r *Registry) allElementTypes() elementTypesMap {
+	types := make(elementTypesMap)
+	for ; r != nil; r = r.parent {
+		r.RLock()
+		for name, elemType := range r.elementTypes {
+			// Types in the child override the parent's
+			_, ok := types[name]
+			if !ok {
+				types[name] = elemType
+			}
+		}
+		r.RUnlock()
+	}
+	return types
+}
+
+// Function to make the registry strict
+// (or not). A strict registry requires
+// XML element names to have a namespace
+// (with a prefix or a default xmlns) that
+// exactly matches an element type's. Until
+// it's set, the registry is as strict as
+// its parent
+func (r *Registry) SetStrict(strict bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.strict = &strict
+}
+
+// Function to determine whether the
+// registry (or, if it hasn't been set,
+// the nearest parent that has) is strict
+func (r *Registry) IsStrict() bool {
+	for ; r != nil; r = r.parent {
+		r.RLock()
+		strict := r.strict
+		r.RUnlock()
+		if strict != nil {
+			return *strict
+		}
+	}
+	return false
+}
+
+// Function to find the element type for
+// the given XML name. If there isn't an
+// exact match and name.Space is empty (and
+// the registry isn't strict), the function
+// tries the namespaces in parent.GetNamespaces()
+// then any element type with a matching
+// name.Local. Returns an AmbiguousElementError
+// if either finds more than one element type
+func (r *Registry) resolveElementType(name xml.Name, parent Layout) (elementType, error) {
+	// Try to get the element type
+	elemType, ok := r.lookupElementType(name)
+	if ok {
+		return elemType, nil
+	}
+	// If it wasn't found, it can only
+	// be resolved without a namespace
+	if name.Space != "" {
+		return elementType{}, UnknownElementError{Name: name}
+	}
+	if r.IsStrict() {
+		return elementType{}, UnknownElementError{Name: name, NeedsNamespace: true}
+	}
+
+	// Function to pick the only element
+	// type out of the given ones
+	pick := func(types []elementType) (elementType, error) {
+		if len(types) == 1 {
+			return types[0], nil
+		}
+		candidates := make([]xml.Name, len(types))
+		for i, t := range types {
+			candidates[i] = t.Name
+		}
+		// Sort them, so the error is always the same
+		sort.Slice(candidates, func(i, j int) bool {
+			return XMLNameToString(candidates[i]) < XMLNameToString(candidates[j])
+		})
+		return elementType{}, AmbiguousElementError{name, candidates}
+	}
+
+	// Try the parent's namespaces
+	if parent != nil {
+		var matches []elementType
+		for _, ns := range parent.GetNamespaces() {
+			elemType, ok = r.lookupElementType(xml.Name{
+				Local: name.Local, Space: ns})
+			if ok {
+				matches = append(matches, elemType)
+			}
+		}
+		if len(matches) > 0 {
+			return pick(matches)
+		}
+	}
+
+	// Try every element type
+	var matches []elementType
+	for elemTypeName, elemType := range r.allElementTypes() {
+		if elemTypeName.Local == name.Local {
+			matches = append(matches, elemType)
+		}
+	}
+	if len(matches) > 0 {
+		return pick(matches)
+	}
+	return elementType{}, UnknownElementError{Name: name}
+}
+
+// Function to create an element with the
+// given XML name (see resolveElementType),
+// with the given parent. Returns an
+// UnknownElementError if there isn't an
+// element type for the name
+func (r *Registry) New(fs http.FileSystem, name xml.Name, parent Layout) (Element, error) {
+	elemType, err := r.resolveElementType(name, parent)
+	if err != nil {
+		return nil, err
+	}
+	e := elemType.Factory(fs, name, parent)
+
+	// Make sure the element uses this registry
+	// (e.g. for its attributes and children)
+	holder, ok := e.(registryHolder)
+	if ok {
+		holder.setRegistry(r)
+	}
+	return e, nil
+}
+
+// Function to parse an attribute
+// string into the given type
+func (r *Registry) ParseAttr(t reflect.Type, v string) (reflect.Value, error) {
+	// Try to get the attribute parser
+	for reg := r; reg != nil; reg = reg.parent {
+		reg.RLock()
+		parser, ok := reg.attributeTypes[t]
+		reg.RUnlock()
+		// If it was found, call the parser
+		if ok {
+			return parser(v)
+		}
+	}
+	return reflect.Value{}, errors.New(
+		"unknown attribute type '" + t.Name() + "'")
+}
+
+// Interface type for an element
+// that knows which registry it
+// was created with
+type registryHolder interface {
+	setRegistry(r *Registry)
+}
@@ -0,0 +1,244 @@
+package element_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/bhollier/ui/pkg/ui/builtin"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// The namespace of the test element types
+const testNS = "http://example.com/test"
+
+// Function to register an image element
+// type with the given name in the registry
+func registerPicture(r *element.Registry, name xml.Name) {
+	r.Register(name, reflect.TypeOf((*builtin.Image)(nil)).Elem(), builtin.NewImage)
+}
+
+// Function to load the given XML with the given
+// registry, returning the loading error
+func loadWith(r *element.Registry, design string) error {
+	fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(design)}})
+	_, err := element.NewRoot(fs, nil, "design.xml", r)
+	return err
+}
+
+// Type for a test attribute type
+type testAttr string
+
+func TestRegistryIsStrict(t *testing.T) {
+	// Function to make a setting
+	set := func(strict bool) *bool { return &strict }
+	tests := []struct {
+		name string
+		// The settings of the registries,
+		// from the root registry to the
+		// child (nil if it isn't set)
+		settings []*bool
+		want     bool
+	}{
+		{"unset", []*bool{nil}, false},
+		{"strict", []*bool{set(true)}, true},
+		{"inherited from parent", []*bool{set(true), nil}, true},
+		{"inherited from grandparent", []*bool{set(true), nil, nil}, true},
+		{"overridden by child", []*bool{set(true), set(false)}, false},
+		{"only the child", []*bool{nil, set(true)}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var r *element.Registry
+			for _, setting := range test.settings {
+				r = element.NewRegistry(r)
+				if setting != nil {
+					r.SetStrict(*setting)
+				}
+			}
+			if got := r.IsStrict(); got != test.want {
+				t.Errorf("got strict %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStrictParentRegistry(t *testing.T) {
+	strict := element.NewRegistry(element.DefaultRegistry())
+	strict.SetStrict(true)
+	r := element.NewRegistry(strict)
+
+	// A name without a namespace isn't
+	// allowed by the child registry either
+	fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(
+		`<LinearLayout builtin:width="10px" builtin:height="10px" ` + ns + `/>`)}})
+	_, err := element.NewRoot(fs, nil, "design.xml", r)
+	var unknownErr element.UnknownElementError
+	if !errors.As(err, &unknownErr) || !unknownErr.NeedsNamespace {
+		t.Errorf("got error %v, want an UnknownElementError needing a namespace", err)
+	}
+
+	// Unless it isn't strict
+	r.SetStrict(false)
+	_, err = element.NewRoot(fs, nil, "design.xml", r)
+	if err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+}
+
+func TestRegistryIsolation(t *testing.T) {
+	name := xml.Name{Space: testNS, Local: "Picture"}
+	design := `<test:Picture xmlns:test="` + testNS + `" ` + ns +
+		` builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>`
+	attrType := reflect.TypeOf(testAttr(""))
+	parseAttr := func(v string) (reflect.Value, error) { return reflect.ValueOf(testAttr(v)), nil }
+
+	// Add to one registry
+	r := element.NewRegistry(element.DefaultRegistry())
+	registerPicture(r, name)
+	r.RegisterAttrType(attrType, parseAttr)
+	r.RegisterCallback("save", func(*element.CallbackContext) error { return nil })
+
+	if err := loadWith(r, design); err != nil {
+		t.Errorf("got error %v loading with the registry, want none", err)
+	}
+	if _, err := r.ParseAttr(attrType, "value"); err != nil {
+		t.Errorf("got error %v parsing the attribute with the registry, want none", err)
+	}
+	if _, ok := r.Callbacks().Lookup("save"); !ok {
+		t.Errorf("the registry doesn't have the callback")
+	}
+
+	// Which its parent and a sibling don't have
+	for _, other := range []struct {
+		name string
+		r    *element.Registry
+	}{
+		{"the default registry", element.DefaultRegistry()},
+		{"a sibling registry", element.NewRegistry(element.DefaultRegistry())},
+	} {
+		var unknownErr element.UnknownElementError
+		if err := loadWith(other.r, design); !errors.As(err, &unknownErr) {
+			t.Errorf("got error %v loading with %s, want an UnknownElementError", err, other.name)
+		}
+		if _, err := other.r.ParseAttr(attrType, "value"); err == nil {
+			t.Errorf("%s can parse the attribute", other.name)
+		}
+		if _, ok := other.r.Callbacks().Lookup("save"); ok {
+			t.Errorf("%s has the callback", other.name)
+		}
+	}
+
+	// But the registry has its parent's
+	if _, err := r.ParseAttr(reflect.TypeOf(false), "true"); err != nil {
+		t.Errorf("got error %v parsing a builtin attribute type, want none", err)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	// Registries are added to and
+	// used from multiple goroutines
+	shared := element.NewRegistry(element.DefaultRegistry())
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := xml.Name{Space: testNS, Local: "Picture" + strconv.Itoa(i)}
+			registerPicture(shared, name)
+			shared.RegisterCallback(name.Local, func(*element.CallbackContext) error { return nil })
+			errs[i] = loadWith(shared, `<test:`+name.Local+` xmlns:test="`+testNS+`" `+ns+
+				` builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>`)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("got error %v loading picture %d, want none", err, i)
+		}
+		if _, ok := shared.Callbacks().Lookup("Picture" + strconv.Itoa(i)); !ok {
+			t.Errorf("the callback for picture %d wasn't registered", i)
+		}
+	}
+}
+
+func TestResolveElementType(t *testing.T) {
+	a := xml.Name{Space: "http://example.com/a", Local: "Picture"}
+	b := xml.Name{Space: "http://example.com/b", Local: "Picture"}
+	image := reflect.TypeOf((*builtin.Image)(nil)).Elem()
+	// The namespace of the element
+	// type that was used
+	var used string
+	factory := func(space string) element.Factory {
+		return func(fs http.FileSystem, name xml.Name, parent element.Layout) element.Element {
+			used = space
+			return builtin.NewImage(fs, name, parent)
+		}
+	}
+	// The parent registry has a picture in
+	// namespace a, and the child in b (which
+	// is looked in first, so the candidates
+	// have to be sorted)
+	parent := element.NewRegistry(element.DefaultRegistry())
+	child := element.NewRegistry(parent)
+	child.Register(b, image, factory(b.Space))
+	parent.Register(a, image, factory(a.Space))
+
+	// Function to put a picture in a layout
+	// with the given namespace attributes
+	inLayout := func(attrs string) string {
+		return `<LinearLayout ` + ns + ` ` + attrs +
+			` builtin:width="match_parent" builtin:height="match_parent">` +
+			`<Picture builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>` +
+			`</LinearLayout>`
+	}
+	tests := []struct {
+		name     string
+		registry *element.Registry
+		design   string
+		// The namespace of the element type
+		// that should be used (or "", if it's
+		// ambiguous)
+		want string
+	}{
+		{"with a namespace", child, `<b:Picture xmlns:b="` + b.Space + `" ` + ns +
+			` builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>`, b.Space},
+		{"only in the parent registry", parent, inLayout(""), a.Space},
+		{"ambiguous", child, inLayout(""), ""},
+		{"in the layout's namespaces", child, inLayout(`xmlns:a="` + a.Space + `"`), a.Space},
+		{"ambiguous in the layout's namespaces", child,
+			inLayout(`xmlns:a="` + a.Space + `" xmlns:b="` + b.Space + `"`), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			used = ""
+			err := loadWith(test.registry, test.design)
+			if test.want != "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				if used != test.want {
+					t.Errorf("got the element type in '%s', want the one in '%s'", used, test.want)
+				}
+				return
+			}
+
+			// The candidates are always in the same order
+			var ambiguousErr element.AmbiguousElementError
+			if !errors.As(err, &ambiguousErr) {
+				t.Fatalf("got error %v, want an AmbiguousElementError", err)
+			}
+			want := []xml.Name{a, b}
+			if len(ambiguousErr.Candidates) != len(want) ||
+				ambiguousErr.Candidates[0] != want[0] || ambiguousErr.Candidates[1] != want[1] {
+				t.Errorf("got candidates %v, want %v", ambiguousErr.Candidates, want)
+			}
+		})
+	}
+}
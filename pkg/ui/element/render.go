@@ -0,0 +1,187 @@
+package element
+
+import (
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+	"image/color"
+)
+
+// Interface type for something that
+// can be drawn onto, such as an
+// element's canvas
+type Surface interface {
+	// Function to get the surface's
+	// bounds
+	Bounds() pixel.Rect
+	// Function to set the surface's
+	// bounds
+	SetBounds(pixel.Rect)
+
+	// Function to restrict drawing to
+	// the given rectangle of the surface,
+	// so anything outside it is left as
+	// it was. If nil, the whole surface
+	// can be drawn onto
+	SetClip(*pixel.Rect)
+
+	// Function to clear the surface
+	// (or just its clip rectangle)
+	// with the given colour
+	Clear(color.Color)
+
+	// Function to draw a sprite onto
+	// the surface. The sprite is centered
+	// on (0, 0) before the matrix is
+	// applied
+	DrawSprite(s *pixel.Sprite, mat pixel.Matrix)
+	// Function to draw some text onto
+	// the surface. The baseline of the
+	// first line starts at (0, 0) before
+	// the matrix is applied
+	DrawText(str string, face font.Face, col color.Color, mat pixel.Matrix)
+	// Function to draw another surface
+	// (created by the same renderer)
+	// onto this surface, at the other
+	// surface's bounds
+	DrawSurface(child Surface)
+}
+
+// Interface type for something that
+// creates surfaces and displays them,
+// such as a window
+type Renderer interface {
+	// Function to get the renderer's
+	// bounds
+	Bounds() pixel.Rect
+
+	// Function to create a new surface
+	// with the given bounds
+	NewSurface(bounds pixel.Rect) Surface
+
+	// Function to draw the given surface
+	// onto the renderer's output and
+	// display it. Only the given regions
+	// of the surface (which changed since
+	// it was last presented) are drawn.
+	// If nil, all of it is
+	Present(s Surface, damage []pixel.Rect)
+}
+
+// Type for a mouse button
+type MouseButton int
+
+const (
+	// The left mouse button
+	MouseButtonLeft = MouseButton(iota)
+	// The right mouse button
+	MouseButtonRight
+	// The middle mouse button
+	MouseButtonMiddle
+)
+
+// Type for a key on the keyboard
+type Key int
+
+const (
+	// The special keys
+	KeySpace = Key(iota)
+	KeyEnter
+	KeyEscape
+	KeyTab
+	KeyBackspace
+	KeyInsert
+	KeyDelete
+	KeyRight
+	KeyLeft
+	KeyDown
+	KeyUp
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyLeftShift
+	KeyRightShift
+	KeyLeftControl
+	KeyRightControl
+	KeyLeftAlt
+	KeyRightAlt
+
+	// The number keys (above
+	// the letters)
+	Key0
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+
+	// The letter keys
+	KeyA
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+
+	// The number of keys
+	numKeys
+)
+
+// Interface type for something that
+// provides the user's input
+type Input interface {
+	// Function to determine whether
+	// the mouse is inside the window
+	MouseInsideWindow() bool
+	// Function to get the mouse's
+	// position
+	MousePosition() pixel.Vec
+	// Function to get how much the
+	// mouse wheel scrolled since the
+	// last event
+	MouseScroll() pixel.Vec
+	// Function to determine whether
+	// the given mouse button is
+	// currently pressed
+	MousePressed(MouseButton) bool
+
+	// Function to determine whether
+	// the given key is currently
+	// pressed
+	KeyPressed(Key) bool
+	// Function to get the text typed
+	// since the last event
+	Typed() string
+}
+
+// Interface type for a window, which
+// is both a renderer and a source
+// of input
+type Window interface {
+	Renderer
+	Input
+}
@@ -1,8 +1,9 @@
 package element
 
 import (
+	"bytes"
 	"encoding/xml"
-	"errors"
+	"io/ioutil"
 	"net/http"
 )
 
@@ -13,30 +14,68 @@ type Root struct {
 	parent Layout
 	// The filesystem to use
 	fs http.FileSystem
+	// The registry to create the
+	// elements with
+	registry *Registry
+	// The XML file
+	file *sourceFile
 	// The root element itself
 	Element
 }
 
 // Function to create a new design from
-// an XML string
-func NewRoot(fs http.FileSystem, parent Layout, path string) (e *Root, err error) {
+// an XML string. The elements are created
+// with the given registry (or the parent's,
+// or the default registry, if nil)
+func NewRoot(fs http.FileSystem, parent Layout, path string,
+	registry *Registry) (e *Root, err error) {
+	// If the root is imported, it's
+	// part of the parent's load
+	var state *loadState
+	if parent != nil {
+		state = loadStateOf(parent)
+	}
+	return newRoot(fs, parent, path, registry, state)
+}
+
+// Function to create a new design from
+// an XML string, as part of the given load
+// (which can be nil)
+func newRoot(fs http.FileSystem, parent Layout, path string,
+	registry *Registry, state *loadState) (e *Root, err error) {
 	// Create a new root struct
 	e = new(Root)
 	e.parent = parent
 	e.fs = fs
+	e.registry = registry
+	if e.registry == nil {
+		if parent != nil {
+			e.registry = parent.GetRegistry()
+		} else {
+			e.registry = defaultRegistry
+		}
+	}
 
 	// Open the file
 	file, err := fs.Open(path)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
+	// Read it (so errors can say
+	// where in it they are)
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	e.file = &sourceFile{path, content, state}
 
 	// Create an xml decoder
-	d := xml.NewDecoder(file)
+	d := xml.NewDecoder(bytes.NewReader(content))
 	// Decode into this element
 	err = d.Decode(e)
 	if err != nil {
-		return nil, err
+		return nil, newLoadError(err, e.file, d.InputOffset(), nil)
 	}
 
 	return
@@ -47,17 +86,18 @@ func NewRoot(fs http.FileSystem, parent Layout, path string) (e *Root, err error
 // only called by xml.Unmarshal
 func (e *Root) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
 	// Create an element of the type
-	e.Element = New(e.fs, start.Name, e.parent)
-	// If the element was created
-	if e.Element != nil {
-		// Decode the XML element into it
-		err := decoder.DecodeElement(e.Element, &start)
-		if err != nil {
-			return err
-		}
-		return nil
-	} else {
-		return errors.New("unknown element type '" +
-			XMLNameToString(start.Name) + "'")
+	var err error
+	e.Element, err = e.registry.New(e.fs, start.Name, e.parent)
+	if err != nil {
+		return newLoadError(err, e.file, decoder.InputOffset(), nil)
+	}
+	// The root element is at the
+	// start of the file
+	holder, ok := e.Element.(sourceHolder)
+	if ok {
+		holder.setSource(e.file, decoder.InputOffset())
 	}
+	// Decode the XML element into it
+	err = decoder.DecodeElement(e.Element, &start)
+	return WrapLoadError(err, e.Element)
 }
@@ -0,0 +1,459 @@
+package element
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/bhollier/ui/pkg/ui/util"
+	"image/color"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Type for how an attribute type
+// is described in the XML schema
+type AttrSchema struct {
+	// The name of the schema type (e.g.
+	// "relative-size"). If it's an XML
+	// Schema type (e.g. "xs:int") and there
+	// isn't a pattern or an enumeration,
+	// it's used as is
+	Name string
+	// The regular expression the
+	// attribute has to match (or "")
+	Pattern string
+	// The values the attribute
+	// can be (or nil)
+	Enumeration []string
+}
+
+// Type for an attribute schema map
+type attributeSchemasMap map[reflect.Type]AttrSchema
+
+// The schema of attribute types
+// that don't have one
+var stringAttrSchema = AttrSchema{Name: "xs:string"}
+
+// The schema of the content of
+// elements without children
+var noContentSchema = AttrSchema{Name: "no-content", Pattern: `\s*`}
+
+// Function to determine whether the
+// attribute schema is used as is
+// (rather than being declared)
+func (s AttrSchema) isBuiltin() bool {
+	return strings.HasPrefix(s.Name, "xs:") &&
+		s.Pattern == "" && len(s.Enumeration) == 0
+}
+
+// Function to make a pattern that matches
+// any of the given values, ignoring their
+// case (as XSD patterns can't)
+func caseInsensitivePattern(values ...string) string {
+	patterns := make([]string, len(values))
+	for i, value := range values {
+		var pattern strings.Builder
+		for _, r := range value {
+			lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+			if lower != upper {
+				pattern.WriteString("[" + string(lower) + string(upper) + "]")
+			} else {
+				pattern.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		patterns[i] = pattern.String()
+	}
+	return strings.Join(patterns, "|")
+}
+
+// Function to initialise the
+// attribute schemas map
+func init() {
+	// Get the gravity types (in order,
+	// so the schema is always the same)
+	gravities := make([]string, 0, len(util.GravityTypes))
+	for name := range util.GravityTypes {
+		gravities = append(gravities, name)
+	}
+	sort.Strings(gravities)
+
+	// Create the attribute schemas map, with
+	// all the types in the attribute types map,
+	// in the default registry
+	defaultRegistry.attributeSchemas = attributeSchemasMap{
+		reflect.TypeOf((*string)(nil)).Elem(): stringAttrSchema,
+		// strconv.ParseBool's values (in any case)
+		reflect.TypeOf((*bool)(nil)).Elem(): {Name: "bool",
+			Pattern: `\s*(` + caseInsensitivePattern("1", "t", "true", "0", "f", "false") + `)\s*`},
+		reflect.TypeOf((*int)(nil)).Elem():     {Name: "xs:long"},
+		reflect.TypeOf((*int8)(nil)).Elem():    {Name: "xs:byte"},
+		reflect.TypeOf((*int16)(nil)).Elem():   {Name: "xs:short"},
+		reflect.TypeOf((*int32)(nil)).Elem():   {Name: "xs:int"},
+		reflect.TypeOf((*int64)(nil)).Elem():   {Name: "xs:long"},
+		reflect.TypeOf((*uint)(nil)).Elem():    {Name: "xs:unsignedLong"},
+		reflect.TypeOf((*uint8)(nil)).Elem():   {Name: "xs:unsignedByte"},
+		reflect.TypeOf((*uint16)(nil)).Elem():  {Name: "xs:unsignedShort"},
+		reflect.TypeOf((*uint32)(nil)).Elem():  {Name: "xs:unsignedInt"},
+		reflect.TypeOf((*uint64)(nil)).Elem():  {Name: "xs:unsignedLong"},
+		reflect.TypeOf((*float32)(nil)).Elem(): {Name: "xs:float"},
+		reflect.TypeOf((*float64)(nil)).Elem(): {Name: "xs:double"},
+		// time.ParseDuration's format (e.g. "1m30s")
+		reflect.TypeOf((*time.Duration)(nil)).Elem(): {Name: "duration",
+			Pattern: `[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+|[-+]?0`},
+		// A hex colour, with an optional alpha
+		reflect.TypeOf((*color.RGBA)(nil)).Elem(): {Name: "color",
+			Pattern: "#?([0-9a-fA-F]{6}|[0-9a-fA-F]{8})"},
+		reflect.TypeOf((*util.Unit)(nil)).Elem(): {Name: "unit", Enumeration: []string{
+			string(util.Pixels), string(util.Percent)}},
+		// The sizes are parsed ignoring their case
+		// and whitespace (though the schema doesn't
+		// allow whitespace inside the keywords)
+		reflect.TypeOf((*util.RelativeSize)(nil)).Elem(): {Name: "relative-size",
+			Pattern: `\s*(` + caseInsensitivePattern("match_parent", "match_content", "match_bounds") +
+				`|\+?\s*([0-9]\s*)+(` + caseInsensitivePattern(string(util.Pixels), string(util.Percent)) + `))\s*`},
+		reflect.TypeOf((*util.AbsoluteQuantity)(nil)).Elem(): {Name: "absolute-size",
+			Pattern: `\s*\+?\s*([0-9]\s*)+(` + caseInsensitivePattern(string(util.Pixels)) + `)\s*`},
+		// If it isn't given, it's the default
+		reflect.TypeOf((*util.Gravity)(nil)).Elem(): {Name: "gravity",
+			Pattern: `(` + caseInsensitivePattern(gravities...) + `)?`},
+		reflect.TypeOf((*util.Ratio)(nil)).Elem(): {Name: "ratio",
+			Pattern: "[-+]?[0-9]+:[-+]?[0-9]+"},
+		reflect.TypeOf((*util.Orientation)(nil)).Elem(): {Name: "orientation",
+			Pattern: `(` + caseInsensitivePattern(string(util.HorizontalOrientation),
+				string(util.VerticalOrientation)) + `)?`},
+		reflect.TypeOf((*util.ScaleOption)(nil)).Elem(): {Name: "scale-option",
+			Pattern: caseInsensitivePattern(string(util.NoScale), string(util.ScaleToFill),
+				string(util.ScaleToFit), string(util.Stretch), string(util.Tiled))},
+	}
+}
+
+// Function to register how an attribute
+// type is described in the XML schema,
+// in the default registry
+func RegisterAttrSchema(t reflect.Type, s AttrSchema) {
+	defaultRegistry.RegisterAttrSchema(t, s)
+}
+
+// Function to get how the given attribute
+// type is described in the XML schema
+func (r *Registry) lookupAttrSchema(t reflect.Type) AttrSchema {
+	for ; r != nil; r = r.parent {
+		r.RLock()
+		s, ok := r.attributeSchemas[t]
+		r.RUnlock()
+		if ok && s.Name != "" {
+			return s
+		}
+	}
+	return stringAttrSchema
+}
+
+// Interface type for a layout that gives
+// its children extra attributes (e.g. which
+// element they go to the left of)
+type ChildAttrsLayout interface {
+	// Function to get the struct type (with
+	// uixml tags) of the children's attributes.
+	// It's called on a zero value
+	ChildAttrs() reflect.Type
+}
+
+// Interface type for a layout that limits
+// how many children it can have in XML
+type ChildLimiter interface {
+	// Function to get the most children the
+	// layout can have in XML (or -1 if there
+	// isn't a limit). It's called on a zero value
+	MaxChildren() int
+}
+
+// Type for an attribute in the XML schema
+type schemaAttr struct {
+	// The attribute's name
+	Name xml.Name
+	// The attribute's schema type
+	Schema AttrSchema
+	// Whether the attribute is optional
+	Optional bool
+}
+
+// Function to get the attributes in the XML
+// schema for the given struct type (sorted by
+// name, with later fields overriding earlier
+// ones with the same name like in SetAttrs)
+func (r *Registry) schemaAttrs(t reflect.Type) ([]schemaAttr, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	fields, err := findAttrFields(t)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make(map[xml.Name]schemaAttr, len(fields))
+	for _, field := range fields {
+		attrs[field.Name] = schemaAttr{
+			Name:     field.Name,
+			Schema:   r.lookupAttrSchema(field.Type),
+			Optional: field.Optional,
+		}
+	}
+	sorted := make([]schemaAttr, 0, len(attrs))
+	for _, attr := range attrs {
+		sorted = append(sorted, attr)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return XMLNameToString(sorted[i].Name) < XMLNameToString(sorted[j].Name)
+	})
+	return sorted, nil
+}
+
+// Function to write an XML schema (XSD) for
+// the element types in the registry (and its
+// parents) with the given namespace, from their
+// uixml tags. Attributes are qualified (e.g.
+// "builtin:width"), and ones in other namespaces
+// are allowed but not checked. Layouts that give
+// their children extra attributes (see
+// ChildAttrsLayout) have an attribute group, which
+// every element can use (as XSD can't depend on
+// the parent element)
+func (r *Registry) WriteSchema(w io.Writer, namespace string) error {
+	allTypes := r.allElementTypes()
+
+	// Get the element types in the namespace
+	// and the layouts' children's attributes
+	// (in order, so the schema is always the same)
+	elemTypes := make([]elementType, 0)
+	childAttrTypes := make([]elementType, 0)
+	layoutType := reflect.TypeOf((*Layout)(nil)).Elem()
+	for name, elemType := range allTypes {
+		if name.Space == namespace {
+			elemTypes = append(elemTypes, elemType)
+		}
+		if reflect.PtrTo(elemType.ReflectType).Implements(layoutType) {
+			_, ok := reflect.New(elemType.ReflectType).Interface().(ChildAttrsLayout)
+			if ok {
+				childAttrTypes = append(childAttrTypes, elemType)
+			}
+		}
+	}
+	byName := func(types []elementType) {
+		sort.Slice(types, func(i, j int) bool {
+			return XMLNameToString(types[i].Name) < XMLNameToString(types[j].Name)
+		})
+	}
+	byName(elemTypes)
+	byName(childAttrTypes)
+
+	// The schema types that are used
+	simpleTypes := make(map[string]AttrSchema)
+	// Function to get the attributes for
+	// a struct type, noting their types
+	getAttrs := func(name xml.Name, t reflect.Type) ([]schemaAttr, error) {
+		attrs, err := r.schemaAttrs(t)
+		if err != nil {
+			return nil, errors.New(err.Error() + " on element type '" +
+				XMLNameToString(name) + "'")
+		}
+		for _, attr := range attrs {
+			if attr.Name.Space == namespace && !attr.Schema.isBuiltin() {
+				_, ok := simpleTypes[attr.Schema.Name]
+				if !ok {
+					simpleTypes[attr.Schema.Name] = attr.Schema
+				}
+			}
+		}
+		return attrs, nil
+	}
+
+	// Get the children's attribute groups
+	groupNames := make([]string, len(childAttrTypes))
+	groupAttrs := make([][]schemaAttr, len(childAttrTypes))
+	for i, elemType := range childAttrTypes {
+		layout := reflect.New(elemType.ReflectType).Interface().(ChildAttrsLayout)
+		attrs, err := getAttrs(elemType.Name, layout.ChildAttrs())
+		if err != nil {
+			return err
+		}
+		groupNames[i] = elemType.Name.Local + "-children"
+		groupAttrs[i] = attrs
+	}
+
+	// Get the elements' attributes and
+	// how many children they can have
+	elemAttrs := make([][]schemaAttr, len(elemTypes))
+	maxChildren := make([]int, len(elemTypes))
+	for i, elemType := range elemTypes {
+		attrs, err := getAttrs(elemType.Name, elemType.ReflectType)
+		if err != nil {
+			return err
+		}
+		elemAttrs[i] = attrs
+
+		// If it's a layout, it can have children
+		if reflect.PtrTo(elemType.ReflectType).Implements(layoutType) {
+			maxChildren[i] = -1
+			limiter, ok := reflect.New(elemType.ReflectType).Interface().(ChildLimiter)
+			if ok {
+				maxChildren[i] = limiter.MaxChildren()
+			}
+		}
+		// Elements without children can
+		// still have whitespace
+		if maxChildren[i] == 0 {
+			simpleTypes[noContentSchema.Name] = noContentSchema
+		}
+	}
+
+	// Function to escape an attribute value
+	esc := func(s string) string {
+		var buf bytes.Buffer
+		_ = xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	}
+	// Function to write the given attributes
+	// (other than ones in other namespaces,
+	// which are allowed by writeAnyAttr)
+	var buf bytes.Buffer
+	writeAttrs := func(indent string, attrs []schemaAttr, optional bool) {
+		for _, attr := range attrs {
+			if attr.Name.Space != namespace {
+				continue
+			}
+			fmt.Fprintf(&buf, `%s<xs:attribute name="%s" type="%s"`,
+				indent, esc(attr.Name.Local), esc(attr.Schema.Name))
+			if !attr.Optional && !optional {
+				buf.WriteString(` use="required"`)
+			}
+			buf.WriteString("/>\n")
+		}
+	}
+	// Function to write a wildcard for the given
+	// attributes in other namespaces (if any are)
+	writeAnyAttr := func(indent string, attrs []schemaAttr) {
+		for _, attr := range attrs {
+			if attr.Name.Space != namespace {
+				fmt.Fprintf(&buf, "%s<xs:anyAttribute namespace=\"##other\" processContents=\"lax\"/>\n", indent)
+				return
+			}
+		}
+	}
+
+	// Write the header
+	buf.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" ?>\n")
+	buf.WriteString("<!-- Generated by \"ui schema\" (element.WriteSchema), don't edit it by hand -->\n")
+	buf.WriteString("<xs:schema\n        xmlns:xs=\"http://www.w3.org/2001/XMLSchema\"")
+	if namespace != "" {
+		fmt.Fprintf(&buf, "\n        xmlns=\"%s\"\n        targetNamespace=\"%s\"\n"+
+			"        attributeFormDefault=\"qualified\"", esc(namespace), esc(namespace))
+	}
+	buf.WriteString(">\n")
+
+	// Write the schema types
+	typeNames := make([]string, 0, len(simpleTypes))
+	for name := range simpleTypes {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		s := simpleTypes[name]
+		fmt.Fprintf(&buf, "    <xs:simpleType name=\"%s\">\n", esc(name))
+		if s.Pattern == "" && len(s.Enumeration) == 0 {
+			buf.WriteString("        <xs:restriction base=\"xs:string\"/>\n")
+		} else {
+			buf.WriteString("        <xs:restriction base=\"xs:string\">\n")
+			if s.Pattern != "" {
+				fmt.Fprintf(&buf, "            <xs:pattern value=\"%s\"/>\n", esc(s.Pattern))
+			}
+			for _, value := range s.Enumeration {
+				fmt.Fprintf(&buf, "            <xs:enumeration value=\"%s\"/>\n", esc(value))
+			}
+			buf.WriteString("        </xs:restriction>\n")
+		}
+		buf.WriteString("    </xs:simpleType>\n\n")
+	}
+
+	// Write the children's attribute groups
+	for i, name := range groupNames {
+		fmt.Fprintf(&buf, "    <xs:attributeGroup name=\"%s\">\n", esc(name))
+		// Children don't have to use them
+		writeAttrs("        ", groupAttrs[i], true)
+		writeAnyAttr("        ", groupAttrs[i])
+		buf.WriteString("    </xs:attributeGroup>\n\n")
+	}
+
+	// Write the elements
+	for i, elemType := range elemTypes {
+		fmt.Fprintf(&buf, "    <xs:element name=\"%s\">\n", esc(elemType.Name.Local))
+		buf.WriteString("        <xs:complexType>\n")
+		indent := "            "
+
+		if maxChildren[i] == 0 {
+			fmt.Fprintf(&buf, "%s<xs:simpleContent>\n", indent)
+			fmt.Fprintf(&buf, "%s    <xs:extension base=\"%s\">\n", indent, noContentSchema.Name)
+			indent += "        "
+		} else {
+			// The children can be any type
+			// (as they may be plugins)
+			maxOccurs := "unbounded"
+			if maxChildren[i] > 0 {
+				maxOccurs = strconv.Itoa(maxChildren[i])
+			}
+			fmt.Fprintf(&buf, "%s<xs:sequence minOccurs=\"0\" maxOccurs=\"%s\">\n", indent, maxOccurs)
+			fmt.Fprintf(&buf, "%s    <xs:any namespace=\"##any\" processContents=\"lax\"/>\n", indent)
+			fmt.Fprintf(&buf, "%s</xs:sequence>\n", indent)
+		}
+
+		writeAttrs(indent, elemAttrs[i], false)
+		for _, name := range groupNames {
+			fmt.Fprintf(&buf, "%s<xs:attributeGroup ref=\"%s\"/>\n", indent, esc(name))
+		}
+		writeAnyAttr(indent, elemAttrs[i])
+
+		if maxChildren[i] == 0 {
+			indent = indent[:len(indent)-8]
+			fmt.Fprintf(&buf, "%s    </xs:extension>\n", indent)
+			fmt.Fprintf(&buf, "%s</xs:simpleContent>\n", indent)
+		}
+		buf.WriteString("        </xs:complexType>\n")
+		buf.WriteString("    </xs:element>\n")
+		if i < len(elemTypes)-1 {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("</xs:schema>\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Function to write an XML schema for the
+// element types in the default registry
+// with the given namespace (see
+// Registry.WriteSchema)
+func WriteSchema(w io.Writer, namespace string) error {
+	return defaultRegistry.WriteSchema(w, namespace)
+}
+
+// Function to get the namespaces of the
+// element types in the registry (and its
+// parents), in order
+func (r *Registry) Namespaces() []string {
+	set := make(map[string]bool)
+	for name := range r.allElementTypes() {
+		set[name.Space] = true
+	}
+	namespaces := make([]string, 0, len(set))
+	for ns := range set {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
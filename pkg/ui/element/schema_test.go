@@ -0,0 +1,132 @@
+package element_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/util"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// The namespace of the builtin elements
+const builtinNamespace = "http://github.com/bhollier/ui/api/schema"
+
+// The schema of the builtin elements
+var builtinSchema = filepath.Join("..", "..", "..", "api", "schema", "builtin.xsd")
+
+func TestWriteSchemaGolden(t *testing.T) {
+	var buf bytes.Buffer
+	err := element.WriteSchema(&buf, builtinNamespace)
+	if err != nil {
+		t.Fatalf("error writing schema: %v", err)
+	}
+	want, err := ioutil.ReadFile(builtinSchema)
+	if err != nil {
+		t.Fatalf("error reading schema: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("%s is out of date, regenerate it with "+
+			"'go run ./cmd/ui schema -o api/schema/builtin.xsd'", builtinSchema)
+	}
+}
+
+// Type for the schema types
+// in an XML schema
+type schemaTypes struct {
+	SimpleTypes []struct {
+		Name    string `xml:"name,attr"`
+		Pattern struct {
+			Value string `xml:"value,attr"`
+		} `xml:"restriction>pattern"`
+	} `xml:"simpleType"`
+}
+
+func TestSchemaPatternsMatchParsers(t *testing.T) {
+	var buf bytes.Buffer
+	err := element.WriteSchema(&buf, builtinNamespace)
+	if err != nil {
+		t.Fatalf("error writing schema: %v", err)
+	}
+	var schema schemaTypes
+	err = xml.Unmarshal(buf.Bytes(), &schema)
+	if err != nil {
+		t.Fatalf("error reading schema: %v", err)
+	}
+	// The patterns, by the schema type's name
+	// (which are anchored, like XSD patterns)
+	patterns := make(map[string]*regexp.Regexp)
+	for _, s := range schema.SimpleTypes {
+		if s.Pattern.Value != "" {
+			patterns[s.Name] = regexp.MustCompile(`^(?:` + s.Pattern.Value + `)$`)
+		}
+	}
+
+	relativeSize := reflect.TypeOf(util.RelativeSize{})
+	absoluteSize := reflect.TypeOf(util.AbsoluteQuantity{})
+	boolean := reflect.TypeOf(false)
+	orientation := reflect.TypeOf(util.Orientation(""))
+	gravity := reflect.TypeOf(util.Gravity{})
+	scale := reflect.TypeOf(util.ScaleOption(""))
+	colour := reflect.TypeOf(color.RGBA{})
+	tests := []struct {
+		schema string
+		t      reflect.Type
+		value  string
+		valid  bool
+	}{
+		{"relative-size", relativeSize, "match_parent", true},
+		{"relative-size", relativeSize, "MATCH_PARENT", true},
+		{"relative-size", relativeSize, " Match_Content ", true},
+		{"relative-size", relativeSize, "50%", true},
+		{"relative-size", relativeSize, "50 %", true},
+		{"relative-size", relativeSize, "10PX", true},
+		{"relative-size", relativeSize, " 10 px ", true},
+		{"relative-size", relativeSize, "match_parents", false},
+		{"relative-size", relativeSize, "10pc", false},
+		{"relative-size", relativeSize, "-10px", false},
+		{"relative-size", relativeSize, "px", false},
+		{"absolute-size", absoluteSize, "10px", true},
+		{"absolute-size", absoluteSize, "10 PX", true},
+		{"absolute-size", absoluteSize, "10%", false},
+		{"bool", boolean, "true", true},
+		{"bool", boolean, "TrUe", true},
+		{"bool", boolean, " F ", true},
+		{"bool", boolean, "0", true},
+		{"bool", boolean, "yes", false},
+		{"orientation", orientation, "Vertical", true},
+		{"orientation", orientation, "HORIZONTAL", true},
+		{"orientation", orientation, "diagonal", false},
+		{"gravity", gravity, "Top-Left", true},
+		{"gravity", gravity, "top left", false},
+		{"scale-option", scale, "Stretch", true},
+		{"scale-option", scale, "squash", false},
+		{"color", colour, "#FF0000", true},
+		{"color", colour, "ff000080", true},
+		{"color", colour, "#FF00008", false},
+		{"color", colour, "#FF000", false},
+		{"color", colour, "#FF0000800", false},
+		{"color", colour, "#GG0000", false},
+	}
+	for _, test := range tests {
+		t.Run(test.schema+" "+test.value, func(t *testing.T) {
+			pattern, ok := patterns[test.schema]
+			if !ok {
+				t.Fatalf("schema type '%s' doesn't have a pattern", test.schema)
+			}
+			if got := pattern.MatchString(test.value); got != test.valid {
+				t.Errorf("schema type '%s' matches %q: %t, want %t",
+					test.schema, test.value, got, test.valid)
+			}
+			_, err := element.DefaultRegistry().ParseAttr(test.t, test.value)
+			if got := err == nil; got != test.valid {
+				t.Errorf("parsing %q as %v: got error %v, want valid: %t",
+					test.value, test.t, err, test.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package element_test
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"testing"
+)
+
+// Function to scroll the mouse wheel over
+// the given scroll by the given amount,
+// then lay the design out again. Returns
+// whether the scroll used the event
+func scrollBy(t *testing.T, scroll element.Element, delta pixel.Vec) bool {
+	t.Helper()
+	ev := &element.ScrollEvent{
+		EventImpl: element.EventImpl{Type: element.EventScroll},
+		Delta:     delta,
+	}
+	element.DispatchEvent(scroll, ev)
+	window := pixel.R(0, 0, 100, 100)
+	_, err := element.RelayoutUI(scroll, window, &window)
+	if err != nil {
+		t.Fatalf("error laying out design: %v", err)
+	}
+	return ev.IsPropagationStopped()
+}
+
+func TestScrollHorizontalEdges(t *testing.T) {
+	// A 40px wide scroll of 120px of images
+	root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">
+		<Scroll builtin:width="40px" builtin:height="40px">
+			<LinearLayout builtin:orientation="horizontal" builtin:width="match_content" builtin:height="match_parent">
+				<Image builtin:width="40px" builtin:height="40px" builtin:source="#FF0000"/>
+				<Image builtin:width="40px" builtin:height="40px" builtin:source="#00FF00"/>
+				<Image builtin:width="40px" builtin:height="40px" builtin:source="#0000FF"/>
+			</LinearLayout>
+		</Scroll>
+	</LinearLayout>`)
+	window := pixel.R(0, 0, 100, 100)
+	_, err := element.LayoutUI(root, window, &window)
+	if err != nil {
+		t.Fatalf("error laying out design: %v", err)
+	}
+	scroll := child(root, 0)
+	content := child(root, 0, 0)
+
+	// The default scroll rate is 10, and a
+	// negative delta moves the content left
+	steps := []struct {
+		name  string
+		delta float64
+		// The content's left edge
+		want float64
+		used bool
+	}{
+		{"past the left edge", 5, 0, false},
+		{"scrolled", -3, -30, true},
+		{"past the right edge", -100, -80, true},
+		{"at the right edge", -1, -80, false},
+		{"back past the left edge", 100, 0, true},
+	}
+	for _, step := range steps {
+		used := scrollBy(t, scroll, pixel.V(step.delta, 0))
+		if used != step.used {
+			t.Errorf("%s: got event used %t, want %t", step.name, used, step.used)
+		}
+		got := elementRect(content)
+		if got.Min.X != step.want || got.W() != 120 {
+			t.Errorf("%s: got content %v, want its left edge at %v", step.name, got, step.want)
+		}
+	}
+}
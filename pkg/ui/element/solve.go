@@ -0,0 +1,290 @@
+package element
+
+import (
+	"github.com/bhollier/ui/pkg/ui/util"
+	"strings"
+)
+
+// Type for a property of an element
+// that the layout solver resolves
+type LayoutProperty int
+
+const (
+	// The element's actual width
+	LayoutWidth LayoutProperty = iota
+	// The element's actual height
+	LayoutHeight
+	// The bounds the element's parent
+	// gives it (and therefore the
+	// element's position)
+	LayoutBounds
+)
+
+// Function to convert the layout
+// property to a string
+func (p LayoutProperty) String() string {
+	switch p {
+	case LayoutWidth:
+		return "width"
+	case LayoutHeight:
+		return "height"
+	case LayoutBounds:
+		return "bounds"
+	default:
+		return "unknown"
+	}
+}
+
+// Type for a property of a
+// specific element, which is a
+// node in the layout dependency
+// graph
+type LayoutDependency struct {
+	// The element
+	Element Element
+	// The element's property
+	Property LayoutProperty
+}
+
+// Function to convert the layout
+// dependency to a string
+func (d LayoutDependency) String() string {
+	str := d.Property.String() + " of '" + FullName(d.Element, ".", false) + "'"
+	switch d.Property {
+	case LayoutWidth:
+		str += " (" + d.Element.GetRelWidth().String() + ")"
+	case LayoutHeight:
+		str += " (" + d.Element.GetRelHeight().String() + ")"
+	}
+	return str
+}
+
+// Interface type for a layout whose
+// children's bounds depend on more than
+// just the layout's own size and bounds
+// (e.g. the size of the child itself,
+// or of its siblings)
+type ArrangeDependent interface {
+	// Function to get the properties
+	// the given child's bounds depend on
+	ArrangeDependencies(child Element) []LayoutDependency
+}
+
+// Interface type for an element whose
+// width or height depends on more than
+// its relative size says (e.g. a grid
+// whose cells are sized relative to
+// another element)
+type SizeDependent interface {
+	// Function to get the properties the
+	// element's width or height depend on,
+	// as well as the ones its relative
+	// size depends on
+	SizeDependencies(prop LayoutProperty) []LayoutDependency
+}
+
+// Type for an error when the layout
+// properties of an element tree depend
+// on each other in a loop, so the tree
+// can never be laid out
+type LayoutCycleError struct {
+	// The properties in the cycle, where
+	// each depends on the next and the
+	// last is the same as the first
+	Cycle []LayoutDependency
+}
+
+// Function to return the error string
+func (e LayoutCycleError) Error() string {
+	steps := make([]string, len(e.Cycle))
+	for i, dep := range e.Cycle {
+		steps[i] = dep.String()
+	}
+	return "layout dependency cycle detected: " +
+		strings.Join(steps, " depends on ")
+}
+
+// Type for the graph of dependencies
+// between the layout properties of an
+// element tree
+type LayoutGraph struct {
+	// The graph's nodes, in tree order
+	nodes []LayoutDependency
+	// The properties each node depends on
+	edges map[LayoutDependency][]LayoutDependency
+}
+
+// Function to build the layout
+// dependency graph of an element tree
+func NewLayoutGraph(root Element) *LayoutGraph {
+	g := &LayoutGraph{edges: make(map[LayoutDependency][]LayoutDependency)}
+
+	// Recursive function to add an element's nodes
+	var addElement func(Element)
+	addElement = func(e Element) {
+		width := LayoutDependency{e, LayoutWidth}
+		height := LayoutDependency{e, LayoutHeight}
+		bounds := LayoutDependency{e, LayoutBounds}
+		g.nodes = append(g.nodes, width, height, bounds)
+		g.edges[width] = sizeDependencies(e, LayoutWidth, e.GetRelWidth())
+		g.edges[height] = sizeDependencies(e, LayoutHeight, e.GetRelHeight())
+		g.edges[bounds] = boundsDependencies(e)
+		// If the element knows more, ask it
+		dependent, ok := e.(SizeDependent)
+		if ok {
+			g.edges[width] = append(g.edges[width],
+				dependent.SizeDependencies(LayoutWidth)...)
+			g.edges[height] = append(g.edges[height],
+				dependent.SizeDependencies(LayoutHeight)...)
+		}
+
+		// If it's a layout, add the children
+		layout, ok := e.(Layout)
+		if ok {
+			for i := 0; i < layout.NumChildren(); i++ {
+				addElement(layout.GetChild(i))
+			}
+		}
+	}
+	addElement(root)
+
+	return g
+}
+
+// Function to get the properties an
+// element's width or height depend on
+func sizeDependencies(e Element, prop LayoutProperty,
+	rel util.RelativeSize) (deps []LayoutDependency) {
+	switch {
+	// The size depends on the bounds
+	case rel.MatchBounds:
+		deps = append(deps, LayoutDependency{e, LayoutBounds})
+
+	// The size depends on the children
+	// (if the element is a layout,
+	// otherwise it has its own content)
+	case rel.MatchContent:
+		layout, ok := e.(Layout)
+		if ok {
+			for i := 0; i < layout.NumChildren(); i++ {
+				deps = append(deps, LayoutDependency{layout.GetChild(i), prop})
+			}
+		}
+
+	// The size depends on the parent
+	// (or the window, if it's the root)
+	case rel.MatchParent || rel.Unit == util.Percent:
+		if e.GetParent() != nil {
+			deps = append(deps, LayoutDependency{e.GetParent(), prop})
+		}
+	}
+	return
+}
+
+// Function to get the properties
+// an element's bounds depend on
+func boundsDependencies(e Element) []LayoutDependency {
+	// The root's bounds are given
+	parent := e.GetParent()
+	if parent == nil {
+		return nil
+	}
+
+	// If the parent knows better, ask it
+	dependent, ok := parent.(ArrangeDependent)
+	if ok {
+		return dependent.ArrangeDependencies(e)
+	}
+
+	// Otherwise assume the bounds depend
+	// on where the parent is
+	return []LayoutDependency{
+		{parent, LayoutWidth},
+		{parent, LayoutHeight},
+		{parent, LayoutBounds},
+	}
+}
+
+// Function to sort the graph's nodes so
+// each node comes after the nodes it
+// depends on. Returns a LayoutCycleError
+// if the graph has a cycle
+func (g *LayoutGraph) Sort() ([]LayoutDependency, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[LayoutDependency]int, len(g.nodes))
+	sorted := make([]LayoutDependency, 0, len(g.nodes))
+	// The nodes currently being visited
+	path := make([]LayoutDependency, 0)
+
+	// Recursive function to visit a node
+	// (depth first) and its dependencies
+	var visit func(LayoutDependency) error
+	visit = func(node LayoutDependency) error {
+		switch state[node] {
+		case visited:
+			return nil
+		case visiting:
+			// Find where the cycle starts on the path
+			start := len(path) - 1
+			for path[start] != node {
+				start--
+			}
+			cycle := append([]LayoutDependency{}, path[start:]...)
+			return LayoutCycleError{Cycle: append(cycle, node)}
+		}
+
+		state[node] = visiting
+		path = append(path, node)
+		for _, dep := range g.edges[node] {
+			err := visit(dep)
+			if err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+
+		sorted = append(sorted, node)
+		return nil
+	}
+
+	// Visit every node
+	for _, node := range g.nodes {
+		err := visit(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Function to get the given nodes
+// and every node that (directly or
+// indirectly) depends on them
+func (g *LayoutGraph) Dependents(nodes []LayoutDependency) map[LayoutDependency]bool {
+	// Reverse the edges
+	dependents := make(map[LayoutDependency][]LayoutDependency, len(g.nodes))
+	for _, node := range g.nodes {
+		for _, dep := range g.edges[node] {
+			dependents[dep] = append(dependents[dep], node)
+		}
+	}
+
+	// Follow the reversed edges from the given nodes
+	found := make(map[LayoutDependency]bool, len(nodes))
+	queue := append([]LayoutDependency{}, nodes...)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if found[node] {
+			continue
+		}
+		found[node] = true
+		queue = append(queue, dependents[node]...)
+	}
+	return found
+}
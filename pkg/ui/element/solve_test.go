@@ -0,0 +1,156 @@
+package element_test
+
+import (
+	"errors"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"testing"
+)
+
+func TestLayoutGraphSort(t *testing.T) {
+	root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">
+		<LinearLayout builtin:width="match_content" builtin:height="match_content">
+			<Image builtin:width="30px" builtin:height="10px" builtin:source="#FF0000"/>
+			<Image builtin:width="20px" builtin:height="15px" builtin:source="#FF0000"/>
+		</LinearLayout>
+		<Image builtin:width="50%" builtin:height="10px" builtin:source="#FF0000"/>
+		<GridLayout builtin:width="match_content" builtin:height="match_content"
+				builtin:cell-width="10%" builtin:cell-height="10px">
+			<Image builtin:width="5px" builtin:height="5px" builtin:source="#FF0000"/>
+		</GridLayout>
+		<RelativeLayout builtin:width="20px" builtin:height="20px">
+			<Image builtin:width="10px" builtin:height="match_bounds" builtin:right-of="parent"
+				builtin:source="#FF0000"/>
+		</RelativeLayout>
+	</LinearLayout>`)
+	graph := element.NewLayoutGraph(root)
+	order, err := graph.Sort()
+	if err != nil {
+		t.Fatalf("error sorting layout graph: %v", err)
+	}
+
+	// Get where each property is in the order
+	index := make(map[element.LayoutDependency]int, len(order))
+	for i, dep := range order {
+		index[dep] = i
+	}
+	// Every element has a width, height and bounds
+	if len(order) != 9*3 {
+		t.Errorf("got %d properties, want %d", len(order), 9*3)
+	}
+
+	// Function to make a property
+	// of an element by its path
+	prop := func(p element.LayoutProperty, path ...int) element.LayoutDependency {
+		return element.LayoutDependency{Element: child(root, path...), Property: p}
+	}
+	// The properties, and the properties
+	// that must come before them
+	tests := []struct {
+		name        string
+		dep, before element.LayoutDependency
+	}{
+		{"match_content after children",
+			prop(element.LayoutWidth, 0), prop(element.LayoutWidth, 0, 0)},
+		{"match_content after every child",
+			prop(element.LayoutWidth, 0), prop(element.LayoutWidth, 0, 1)},
+		{"match_bounds after bounds",
+			prop(element.LayoutHeight, 3, 0), prop(element.LayoutBounds, 3, 0)},
+		{"bounds after parent bounds",
+			prop(element.LayoutBounds, 0, 0), prop(element.LayoutBounds, 0)},
+		{"bounds after earlier siblings",
+			prop(element.LayoutBounds, 1), prop(element.LayoutHeight, 0)},
+		{"percent after parent",
+			prop(element.LayoutWidth, 1), prop(element.LayoutWidth)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if index[test.before] >= index[test.dep] {
+				t.Errorf("%v (at %d) isn't after %v (at %d)",
+					test.dep, index[test.dep], test.before, index[test.before])
+			}
+		})
+	}
+
+	// The grid's size depends on its parent's
+	// through its percent cell width, which
+	// its children's bounds depend on too
+	dependents := graph.Dependents([]element.LayoutDependency{prop(element.LayoutWidth)})
+	for _, dep := range []element.LayoutDependency{
+		prop(element.LayoutWidth, 2), prop(element.LayoutBounds, 2, 0)} {
+		if !dependents[dep] {
+			t.Errorf("%v doesn't depend on %v", dep, prop(element.LayoutWidth))
+		}
+	}
+	// But its cell height is in pixels
+	dependents = graph.Dependents([]element.LayoutDependency{prop(element.LayoutHeight)})
+	if dependents[prop(element.LayoutHeight, 2)] {
+		t.Errorf("%v depends on %v", prop(element.LayoutHeight, 2), prop(element.LayoutHeight))
+	}
+}
+
+func TestLayoutGraphCycle(t *testing.T) {
+	tests := []struct {
+		name   string
+		design string
+		err    string
+	}{
+		{
+			name: "match_parent child of match_content parent",
+			design: `<LinearLayout ` + ns + ` builtin:width="match_content" builtin:height="10px">
+				<Image builtin:width="match_parent" builtin:height="10px" builtin:source="#FF0000"/>
+			</LinearLayout>`,
+			err: "layout dependency cycle detected: " +
+				"width of 'LinearLayout' (match_content) depends on " +
+				"width of 'LinearLayout.Image' (match_parent) depends on " +
+				"width of 'LinearLayout' (match_content)",
+		},
+		{
+			name: "percent child of match_content parent",
+			design: `<LinearLayout ` + ns + ` builtin:width="10px" builtin:height="match_content">
+				<Image builtin:width="10px" builtin:height="50%" builtin:source="#FF0000"/>
+			</LinearLayout>`,
+			err: "layout dependency cycle detected: " +
+				"height of 'LinearLayout' (match_content) depends on " +
+				"height of 'LinearLayout.Image' (50%) depends on " +
+				"height of 'LinearLayout' (match_content)",
+		},
+		{
+			name: "match_bounds child of match_content parent",
+			design: `<LinearLayout ` + ns + ` builtin:width="match_content" builtin:height="10px">
+				<Image builtin:width="match_bounds" builtin:height="10px" builtin:source="#FF0000"/>
+			</LinearLayout>`,
+			err: "layout dependency cycle detected: " +
+				"width of 'LinearLayout' (match_content) depends on " +
+				"width of 'LinearLayout.Image' (match_bounds) depends on " +
+				"bounds of 'LinearLayout.Image' depends on " +
+				"width of 'LinearLayout' (match_content)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := loadDesign(t, test.design)
+			_, err := element.NewLayoutGraph(root).Sort()
+			var cycleErr element.LayoutCycleError
+			if !errors.As(err, &cycleErr) {
+				t.Fatalf("got error %v, want a LayoutCycleError", err)
+			}
+			if err.Error() != test.err {
+				t.Errorf("got error:\n%s\nwant:\n%s", err, test.err)
+			}
+			// The cycle ends where it starts
+			if len(cycleErr.Cycle) < 2 ||
+				cycleErr.Cycle[0] != cycleErr.Cycle[len(cycleErr.Cycle)-1] {
+				t.Errorf("got cycle %v, want one that ends where it starts",
+					cycleErr.Cycle)
+			}
+
+			// Laying out the design reports the same error
+			window := pixel.R(0, 0, 100, 100)
+			_, err = element.LayoutUI(root, window, &window)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got layout error %v, want the cycle", err)
+			}
+		})
+	}
+}
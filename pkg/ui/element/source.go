@@ -0,0 +1,192 @@
+package element
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Type for a position in an XML file
+type SourcePos struct {
+	// The file's path
+	Path string
+	// The line (starting at 1)
+	Line int
+	// The column (starting at 1,
+	// or 0 if it isn't known)
+	Column int
+}
+
+// Function to convert the position
+// to a string (e.g. "main.xml:3:5")
+func (p SourcePos) String() string {
+	str := p.Path + ":" + strconv.Itoa(p.Line)
+	if p.Column > 0 {
+		str += ":" + strconv.Itoa(p.Column)
+	}
+	return str
+}
+
+// Type for an XML file that
+// elements are loaded from
+type sourceFile struct {
+	// The file's path
+	path string
+	// The file's contents
+	content []byte
+	// The load the file is part of
+	// (or nil)
+	load *loadState
+}
+
+// Function to get the position of the
+// XML element whose start tag ends at
+// (or includes) the given offset
+func (f *sourceFile) pos(offset int64) SourcePos {
+	if offset > int64(len(f.content)) {
+		offset = int64(len(f.content))
+	}
+	// Go back to the start of the tag
+	// ('<' can't be in an attribute value)
+	start := bytes.LastIndexByte(f.content[:offset], '<')
+	if start < 0 {
+		start = int(offset)
+	}
+	before := f.content[:start]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return SourcePos{
+		Path:   f.path,
+		Line:   bytes.Count(before, []byte{'\n'}) + 1,
+		Column: utf8.RuneCount(before[lineStart:]) + 1,
+	}
+}
+
+// Interface type for an element
+// that knows where in its XML
+// file it is
+type sourceHolder interface {
+	// Function to get the element's file
+	// and the offset of its start tag
+	getSource() (*sourceFile, int64)
+	// Function to set the element's file
+	// and the offset of its start tag
+	setSource(file *sourceFile, offset int64)
+}
+
+// Interface type for an element that
+// wraps another element (e.g. to give
+// it extra attributes)
+type ElementWrapper interface {
+	// Function to get the wrapped element
+	UnwrapElement() Element
+}
+
+// Function to get the element (or
+// the element it wraps) that knows
+// where in its XML file it is
+func sourceHolderOf(e Element) (sourceHolder, bool) {
+	wrapper, ok := e.(ElementWrapper)
+	if ok {
+		e = wrapper.UnwrapElement()
+	}
+	holder, ok := e.(sourceHolder)
+	return holder, ok
+}
+
+// Function to get where in its XML
+// file the given element is. Returns
+// false if it isn't known (e.g. the
+// element wasn't loaded from a file)
+func SourcePosition(e Element) (SourcePos, bool) {
+	holder, ok := sourceHolderOf(e)
+	if !ok {
+		return SourcePos{}, false
+	}
+	file, offset := holder.getSource()
+	if file == nil {
+		return SourcePos{}, false
+	}
+	return file.pos(offset), true
+}
+
+// Function to record where in its XML file
+// the given element is, given the decoder
+// that has just read its start element. The
+// file is the one the element's parent is in
+func RecordSourcePos(e Element, d *xml.Decoder) {
+	holder, ok := sourceHolderOf(e)
+	if ok {
+		file, _ := holder.getSource()
+		holder.setSource(file, d.InputOffset())
+	}
+}
+
+// Type for an error when loading a
+// design, which says where it is
+type LoadError struct {
+	// Where the error is
+	Pos SourcePos
+	// The element with the error
+	// (or nil, if it couldn't be created)
+	Element Element
+	// The error itself
+	Err error
+}
+
+// Function to return the error string
+func (e LoadError) Error() string {
+	if e.Pos.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Pos.String() + ": " + e.Err.Error()
+}
+
+// Function to get the error itself
+func (e LoadError) Unwrap() error { return e.Err }
+
+// Function to create a LoadError from an
+// error, unless it already is one (or it's
+// nil). XML syntax errors keep their line
+func newLoadError(err error, file *sourceFile, offset int64, e Element) error {
+	if err == nil || file == nil {
+		return err
+	}
+	var loadErr LoadError
+	if errors.As(err, &loadErr) {
+		return err
+	}
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return LoadError{SourcePos{Path: file.path, Line: syntaxErr.Line}, nil, err}
+	}
+	return LoadError{file.pos(offset), e, err}
+}
+
+// Function to wrap an error from loading the
+// given element in a LoadError with the
+// element's position (unless it's nil or
+// already a LoadError)
+func WrapLoadError(err error, e Element) error {
+	holder, ok := sourceHolderOf(e)
+	if !ok {
+		return err
+	}
+	file, offset := holder.getSource()
+	return newLoadError(err, file, offset, e)
+}
+
+// Function to wrap an error from creating
+// a child of the given parent (whose start
+// element the decoder has just read) in a
+// LoadError with the child's position
+// (unless it's nil or already a LoadError)
+func WrapChildLoadError(err error, parent Element, d *xml.Decoder) error {
+	holder, ok := sourceHolderOf(parent)
+	if !ok {
+		return err
+	}
+	file, _ := holder.getSource()
+	return newLoadError(err, file, d.InputOffset(), nil)
+}
@@ -0,0 +1,166 @@
+package element_test
+
+import (
+	"errors"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadErrorPosition(t *testing.T) {
+	// The design that imports "child.xml"
+	parent := `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>
+	<Import builtin:width="match_parent" builtin:height="match_parent" builtin:path="child.xml"/>
+</LinearLayout>`
+
+	tests := []struct {
+		name string
+		// The files, with the design being
+		// loaded in "design.xml"
+		files map[string]string
+		want  element.SourcePos
+		// Part of the error message
+		err string
+	}{
+		{
+			name: "unknown attribute",
+			files: map[string]string{"design.xml": `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"
+		builtin:colour="red"/>
+</LinearLayout>`},
+			want: element.SourcePos{Path: "design.xml", Line: 3, Column: 2},
+			err:  "unknown attribute 'http://github.com/bhollier/ui/api/schema:colour'",
+		},
+		{
+			name: "missing attribute",
+			files: map[string]string{"design.xml": `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+    <Image builtin:width="10px" builtin:source="#FF0000"/>
+</LinearLayout>`},
+			want: element.SourcePos{Path: "design.xml", Line: 2, Column: 5},
+			err:  "no 'http://github.com/bhollier/ui/api/schema:height' attribute",
+		},
+		{
+			name: "unknown element type",
+			files: map[string]string{"design.xml": `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>  <Picture/>
+</LinearLayout>`},
+			want: element.SourcePos{Path: "design.xml", Line: 2, Column: 80},
+			err:  "unknown element type 'Picture'",
+		},
+		{
+			name: "missing element ID",
+			files: map[string]string{"design.xml": `<RelativeLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+
+	<Image builtin:width="10px" builtin:height="10px" builtin:right-of="parent"
+		builtin:left-of="missing" builtin:source="#FF0000"/>
+</RelativeLayout>`},
+			want: element.SourcePos{Path: "design.xml", Line: 3, Column: 2},
+			err:  "no element found with ID 'missing'",
+		},
+		{
+			name: "in an imported file",
+			files: map[string]string{
+				"design.xml": parent,
+				"child.xml": `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000"/>
+	<Image builtin:width="10px" builtin:source="#FF0000"/>
+</LinearLayout>`,
+			},
+			want: element.SourcePos{Path: "child.xml", Line: 3, Column: 2},
+			err:  "no 'http://github.com/bhollier/ui/api/schema:height' attribute",
+		},
+		{
+			// The decoder doesn't give a column
+			name: "XML syntax error",
+			files: map[string]string{"design.xml": `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000">
+</LinearLayout>`},
+			want: element.SourcePos{Path: "design.xml", Line: 3},
+			err:  "XML syntax error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := make(fstest.MapFS, len(test.files))
+			for path, content := range test.files {
+				files[path] = &fstest.MapFile{Data: []byte(content)}
+			}
+			_, err := element.NewRoot(http.FS(files), nil, "design.xml", nil)
+			var loadErr element.LoadError
+			if !errors.As(err, &loadErr) {
+				t.Fatalf("got error %v, want a LoadError", err)
+			}
+			if loadErr.Pos != test.want {
+				t.Errorf("got error at %v, want %v", loadErr.Pos, test.want)
+			}
+			if !strings.Contains(loadErr.Err.Error(), test.err) {
+				t.Errorf("got error %q, want it to contain %q", loadErr.Err, test.err)
+			}
+			if !strings.HasPrefix(err.Error(), test.want.String()+": ") {
+				t.Errorf("got error %q, want it to start with the position", err)
+			}
+		})
+	}
+}
+
+func TestSourcePosition(t *testing.T) {
+	root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">
+	<ImageButton builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+	<ImageButton builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+		<ImageButton builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"/>
+</LinearLayout>`)
+
+	// Identical elements are told apart
+	tests := []struct {
+		path []int
+		want element.SourcePos
+	}{
+		{nil, element.SourcePos{Path: "design.xml", Line: 1, Column: 1}},
+		{[]int{0}, element.SourcePos{Path: "design.xml", Line: 2, Column: 2}},
+		{[]int{1}, element.SourcePos{Path: "design.xml", Line: 3, Column: 2}},
+		{[]int{2}, element.SourcePos{Path: "design.xml", Line: 4, Column: 3}},
+	}
+	for _, test := range tests {
+		got, ok := element.SourcePosition(child(root, test.path...))
+		if !ok {
+			t.Errorf("element %v: position isn't known", test.path)
+		} else if got != test.want {
+			t.Errorf("element %v: got position %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestValidateDesignPositions(t *testing.T) {
+	fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(`<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+	<Image builtin:width="10px" builtin:source="#FF0000"/>
+	<Image builtin:width="10px" builtin:height="10px" builtin:source="#FF0000" builtin:colour="red"/>
+	<Picture/>
+</LinearLayout>`)}})
+
+	// Every problem is found, in order
+	want := []element.SourcePos{
+		{Path: "design.xml", Line: 2, Column: 2},
+		{Path: "design.xml", Line: 3, Column: 2},
+		{Path: "design.xml", Line: 4, Column: 2},
+	}
+	err := element.ValidateDesign(fs, "design.xml", nil)
+	var loadErrs element.LoadErrors
+	if !errors.As(err, &loadErrs) {
+		t.Fatalf("got error %v, want LoadErrors", err)
+	}
+	if len(loadErrs.Errors) != len(want) {
+		t.Fatalf("got %d problems (%v), want %d", len(loadErrs.Errors), err, len(want))
+	}
+	for i, err := range loadErrs.Errors {
+		var loadErr element.LoadError
+		if !errors.As(err, &loadErr) {
+			t.Errorf("problem %d: got error %v, want a LoadError", i, err)
+		} else if loadErr.Pos != want[i] {
+			t.Errorf("problem %d: got position %v, want %v", i, loadErr.Pos, want[i])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package element
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// Interface type for an element with state
+// that changes while the design runs (e.g. a
+// scroll's position or text set with SetText),
+// which is kept when the design is reloaded
+type StatefulElement interface {
+	// Function to get the element's state
+	// (or nil, if there's nothing to keep)
+	SaveState() interface{}
+	// Function to set the element's state
+	// from another element's SaveState. The
+	// element has been initialised, and the
+	// state may be from a different type of
+	// element (in which case it's ignored)
+	RestoreState(state interface{})
+}
+
+// Function to get the element
+// (or the element it wraps)
+// that has state
+func statefulElementOf(e Element) (StatefulElement, bool) {
+	wrapper, ok := e.(ElementWrapper)
+	if ok {
+		e = wrapper.UnwrapElement()
+	}
+	stateful, ok := e.(StatefulElement)
+	return stateful, ok
+}
+
+// Type for an element's saved state
+type savedState struct {
+	// The element's XML name
+	name xml.Name
+	// The element's state
+	state interface{}
+}
+
+// Type for the saved states of an
+// element tree, by the elements' IDs
+// and by their paths in the tree
+type savedStates struct {
+	byID   map[string]*savedState
+	byPath map[string]*savedState
+	// The IDs used more than once in
+	// either tree (which can't be
+	// matched by)
+	duplicateIDs map[string]bool
+}
+
+// Function to count how many times each
+// ID is used in the given element tree
+func countIDs(e Element, counts map[string]int) {
+	if id := e.GetID(); id != nil {
+		counts[*id]++
+	}
+	// If it's a layout, count the children's
+	layout, ok := e.(Layout)
+	if ok {
+		for i := 0; i < layout.NumChildren(); i++ {
+			countIDs(layout.GetChild(i), counts)
+		}
+	}
+}
+
+// Function to get the path of the
+// given element in its tree, given
+// its parent's path and the names
+// of its earlier siblings. This is
+// like FullName, except siblings with
+// the same name are told apart
+func statePath(e Element, parentPath string, siblings map[string]int) string {
+	name := Name(e, true)
+	path := parentPath + "." + name
+	if n := siblings[name]; n > 0 {
+		path += "[" + strconv.Itoa(n) + "]"
+	}
+	siblings[name]++
+	return path
+}
+
+// Function to save the states of
+// the given element and its children
+func (s *savedStates) save(e Element, path string) {
+	stateful, ok := statefulElementOf(e)
+	if ok {
+		state := stateful.SaveState()
+		if state != nil {
+			saved := &savedState{e.GetName(), state}
+			s.byPath[path] = saved
+			if id := e.GetID(); id != nil {
+				s.byID[*id] = saved
+			}
+		}
+	}
+	// If it's a layout, save the children
+	layout, ok := e.(Layout)
+	if ok {
+		siblings := make(map[string]int)
+		for i := 0; i < layout.NumChildren(); i++ {
+			child := layout.GetChild(i)
+			s.save(child, statePath(child, path, siblings))
+		}
+	}
+}
+
+// Function to restore the states of
+// the given element and its children
+func (s *savedStates) restore(e Element, path string) {
+	stateful, ok := statefulElementOf(e)
+	if ok {
+		// Find the element's old state,
+		// by its ID, then by its path
+		saved, found := (*savedState)(nil), false
+		if id := e.GetID(); id != nil && !s.duplicateIDs[*id] {
+			saved, found = s.byID[*id]
+		}
+		if !found {
+			saved, found = s.byPath[path]
+		}
+		// Only restore the state if it's
+		// from the same type of element
+		if found && saved.name == e.GetName() {
+			stateful.RestoreState(saved.state)
+		}
+	}
+	// If it's a layout, restore the children
+	layout, ok := e.(Layout)
+	if ok {
+		siblings := make(map[string]int)
+		for i := 0; i < layout.NumChildren(); i++ {
+			child := layout.GetChild(i)
+			s.restore(child, statePath(child, path, siblings))
+		}
+	}
+}
+
+// Function to copy the state of the
+// elements in one element tree (see
+// StatefulElement) to the matching
+// elements in another (e.g. when the
+// design is reloaded). Elements are
+// matched by their ID, or (if they
+// don't have one, or it isn't unique)
+// by their path in the tree. Both trees
+// must have been initialised
+func CopyState(from, to Element) {
+	s := savedStates{
+		byID:         make(map[string]*savedState),
+		byPath:       make(map[string]*savedState),
+		duplicateIDs: make(map[string]bool),
+	}
+	// Find the IDs that aren't unique (including
+	// ones on elements without state, which could
+	// otherwise be mistaken for each other)
+	for _, tree := range []Element{from, to} {
+		counts := make(map[string]int)
+		countIDs(tree, counts)
+		for id, n := range counts {
+			if n > 1 {
+				s.duplicateIDs[id] = true
+			}
+		}
+	}
+	s.save(from, Name(from, true))
+	s.restore(to, Name(to, true))
+}
@@ -0,0 +1,142 @@
+package element_test
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"strings"
+	"testing"
+)
+
+// Function to make a scroll (with the given
+// attributes) of the given number of
+// images, which are 40px high
+func scrollDesign(attrs string, images int) string {
+	return `<Scroll ` + attrs + ` builtin:width="match_parent" builtin:height="40px">
+		<LinearLayout builtin:width="match_parent" builtin:height="match_content">` +
+		strings.Repeat(`<Image builtin:width="match_parent" builtin:height="40px" builtin:source="#FF0000"/>`, images) +
+		`</LinearLayout>
+	</Scroll>`
+}
+
+// Function to scroll the given scroll
+// down by the given number of pixels
+func scrollDown(t *testing.T, scroll element.Element, pixels float64) {
+	t.Helper()
+	// The default scroll rate is 10
+	scrollBy(t, scroll, pixel.V(0, -pixels/10))
+}
+
+func TestCopyState(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		// The scrolls to scroll (by their child
+		// indices) and how far, in the old design
+		scroll [][]int
+		by     float64
+		// The tops of the new scrolls' contents
+		// (by the scrolls' child indices)
+		want map[string]float64
+	}{
+		{
+			name:   "by path",
+			from:   scrollDesign("", 3),
+			to:     scrollDesign("", 3),
+			scroll: [][]int{{0}},
+			by:     30,
+			want:   map[string]float64{"0": 130},
+		},
+		{
+			name: "by ID",
+			from: scrollDesign(`builtin:id="scroll"`, 3),
+			// The scroll moved, but has the same ID
+			to: `<LinearLayout builtin:width="match_parent" builtin:height="match_parent">` +
+				scrollDesign(`builtin:id="scroll"`, 3) + `</LinearLayout>`,
+			scroll: [][]int{{0}},
+			by:     30,
+			want:   map[string]float64{"0.0": 130},
+		},
+		{
+			name: "moved without an ID",
+			from: scrollDesign("", 3),
+			to: `<LinearLayout builtin:width="match_parent" builtin:height="match_parent">` +
+				scrollDesign("", 3) + `</LinearLayout>`,
+			scroll: [][]int{{0}},
+			by:     30,
+			want:   map[string]float64{"0.0": 100},
+		},
+		{
+			// Siblings of the same type are told
+			// apart, and duplicate IDs aren't used
+			name: "duplicate IDs",
+			from: `<LinearLayout builtin:width="match_parent" builtin:height="match_parent">` +
+				scrollDesign(`builtin:id="scroll"`, 3) + scrollDesign(`builtin:id="scroll"`, 3) +
+				`</LinearLayout>`,
+			to: `<LinearLayout builtin:width="match_parent" builtin:height="match_parent">` +
+				scrollDesign(`builtin:id="scroll"`, 3) + scrollDesign(`builtin:id="scroll"`, 3) +
+				`</LinearLayout>`,
+			scroll: [][]int{{0, 1}},
+			by:     30,
+			want:   map[string]float64{"0.0": 100, "0.1": 90},
+		},
+		{
+			// The new content is shorter, so it
+			// can't be scrolled as far
+			name:   "clamped",
+			from:   scrollDesign("", 3),
+			to:     scrollDesign("", 2),
+			scroll: [][]int{{0}},
+			by:     80,
+			want:   map[string]float64{"0": 140},
+		},
+		{
+			// The new content is too short to scroll
+			name:   "not scrollable",
+			from:   scrollDesign("", 3),
+			to:     scrollDesign("", 1),
+			scroll: [][]int{{0}},
+			by:     30,
+			want:   map[string]float64{"0": 100},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Function to load and lay out a design
+			// (in a layout, so it's 100px square)
+			load := func(design string) element.Element {
+				root := loadDesign(t, `<LinearLayout `+ns+` builtin:width="match_parent" builtin:height="match_parent">`+
+					design+`</LinearLayout>`)
+				window := pixel.R(0, 0, 100, 100)
+				_, err := element.LayoutUI(root, window, &window)
+				if err != nil {
+					t.Fatalf("error laying out design: %v", err)
+				}
+				return root
+			}
+
+			from := load(test.from)
+			for _, path := range test.scroll {
+				scrollDown(t, child(from, path...), test.by)
+			}
+			to := load(test.to)
+			element.CopyState(from, to)
+			window := pixel.R(0, 0, 100, 100)
+			_, err := element.RelayoutUI(to, window, &window)
+			if err != nil {
+				t.Fatalf("error laying out design: %v", err)
+			}
+
+			for path, want := range test.want {
+				var indices []int
+				for _, i := range strings.Split(path, ".") {
+					indices = append(indices, int(i[0]-'0'))
+				}
+				// The scroll's content
+				content := child(to, append(indices, 0)...)
+				if got := elementRect(content).Max.Y; got != want {
+					t.Errorf("scroll %s: got content top %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
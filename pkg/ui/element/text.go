@@ -1,10 +1,10 @@
 package element
 
 import (
-	"fmt"
+	"github.com/bhollier/ui/pkg/ui/util"
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/text"
 	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
 	"golang.org/x/image/font/gofont/goregular"
 	"image/color"
 	"io/ioutil"
@@ -35,11 +35,11 @@ type Text interface {
 	GetTextSize() float64
 
 	// Function to get the text
-	// "sprite"
-	GetSprite() *text.Text
+	// font face
+	GetFace() font.Face
 	// Function to set the text
-	// "sprite"
-	SetSprite(*text.Text)
+	// font face
+	SetFace(font.Face)
 }
 
 // Implementation of the text interface
@@ -52,8 +52,8 @@ type TextImpl struct {
 	Size float64 `uixml:"http://github.com/bhollier/ui/api/schema text-size,optional"`
 	// The element's text string
 	textStr string
-	// The element's text "sprite"
-	textSprite *text.Text
+	// The element's font face
+	face font.Face
 }
 
 // Function to get the text
@@ -76,9 +76,7 @@ func (t *TextImpl) GetText() string { return t.textStr }
 // should be used instead
 func (t *TextImpl) SetText(s string) error {
 	t.textStr = s
-	t.GetSprite().Clear()
-	_, err := fmt.Fprintf(t.GetSprite(), t.textStr)
-	return err
+	return nil
 }
 
 // Function to get the text
@@ -86,33 +84,36 @@ func (t *TextImpl) SetText(s string) error {
 func (t *TextImpl) GetTextSize() float64 { return t.Size }
 
 // Function to get the text
-// sprite
-func (t *TextImpl) GetSprite() *text.Text { return t.textSprite }
+// font face
+func (t *TextImpl) GetFace() font.Face { return t.face }
 
 // Function to set the text
-// sprite
-func (t *TextImpl) SetSprite(s *text.Text) { t.textSprite = s }
+// font face
+func (t *TextImpl) SetFace(f font.Face) { t.face = f }
 
 // Function to reset the text
 func (t *TextImpl) Reset() {}
 
 // Function to determine whether the
 // text has been initialised, by
-// whether its text sprite has been
+// whether its font face has been
 // set (assuming it's meant to be set).
 // This function doesn't call
 // element.IsInitialised
 func (t *TextImpl) IsInitialised() bool {
 	// If the element doesn't have any text
-	// or the text has been set
-	return t.GetField() == "" || t.GetSprite() != nil
+	// or the face has been set
+	return t.GetField() == "" || t.GetFace() != nil
 }
 
-// Function to initialise an element's
-// text. Doesn't call element.Init
-func InitText(e Element, t Text) error {
-	// If the text hasn't been made yet
-	if t.GetSprite() == nil {
+// Function to measure an element's
+// text (loading the font if needed) and
+// set the element's width and/or height
+// if they should match the text. Doesn't
+// call element.Measure
+func MeasureText(e Element, t Text) error {
+	// If the font face hasn't been made yet
+	if t.GetFace() == nil {
 		// Get the font
 		var ttf *truetype.Font
 		var err error
@@ -149,11 +150,7 @@ func InitText(e Element, t Text) error {
 		}
 
 		// Create a new font face
-		face := truetype.NewFace(ttf, &truetype.Options{Size: textSize})
-		// Make a new text object
-		t.SetSprite(text.New(pixel.V(0, 0), text.NewAtlas(face, text.ASCII)))
-		// Set the text colour
-		t.GetSprite().Color = color.RGBA{R: 0, G: 0, B: 0, A: 255}
+		t.SetFace(truetype.NewFace(ttf, &truetype.Options{Size: textSize}))
 		// Set the text
 		err = t.SetText(t.GetField())
 		if err != nil {
@@ -161,21 +158,24 @@ func InitText(e Element, t Text) error {
 		}
 	}
 
-	// If the sprite has been created
-	if t.GetSprite() != nil {
+	// If the face has been created
+	if t.GetFace() != nil {
+		// Get the size of the text
+		size := util.TextBounds(t.GetFace(), t.GetText()).Size()
+
 		// If the element's width isn't known and
 		// the width is meant to match the content size
 		if e.GetActualWidth() == nil && e.GetRelWidth().MatchContent {
-			// Set the actual width as the size of the text sprite
-			newWidth := t.GetSprite().Bounds().Size().X
+			// Set the actual width as the width of the text
+			newWidth := size.X
 			e.SetActualWidth(&newWidth)
 		}
 
 		// If the element's height isn't known and
 		// the height is meant to match the content size
 		if e.GetActualHeight() == nil && e.GetRelHeight().MatchContent {
-			// Set the actual height as the size of the text sprite
-			newHeight := t.GetSprite().Bounds().Size().Y
+			// Set the actual height as the height of the text
+			newHeight := size.Y
 			e.SetActualHeight(&newHeight)
 		}
 	}
@@ -186,14 +186,15 @@ func InitText(e Element, t Text) error {
 // Function to draw an element's
 // text
 func DrawText(e Element, t Text) {
-	// Draw the text sprite, if it exists
-	if t.GetSprite() != nil {
+	// Draw the text, if the face exists
+	if t.GetFace() != nil {
+		// Get the bounds of the text
+		bounds := util.TextBounds(t.GetFace(), t.GetText())
 		mat := pixel.IM
 		// Move it to the center of the canvas
-		mat = mat.Moved(pixel.V(e.GetCanvas().Bounds().Center().X-(t.GetSprite().Bounds().Size().X/2),
-			e.GetCanvas().Bounds().Center().Y-(t.GetSprite().Bounds().Size().Y/2)))
-		// todo why is it not centered properly?
+		mat = mat.Moved(e.GetCanvas().Bounds().Center().Sub(bounds.Center()))
 		// Draw the text
-		t.GetSprite().Draw(e.GetCanvas(), mat)
+		e.GetCanvas().DrawText(t.GetText(), t.GetFace(),
+			color.RGBA{R: 0, G: 0, B: 0, A: 255}, mat)
 	}
 }
@@ -24,16 +24,6 @@ type elementType struct {
 // Type for an element types map
 type elementTypesMap map[xml.Name]elementType
 
-// Map of element types, with the
-// key being the element's XML name
-var elementTypes elementTypesMap
-
-// Function to initialise the element types map
-func init() {
-	// Create the element types map
-	elementTypes = make(elementTypesMap, 0)
-}
-
 // Function to convert an xml name to a string
 func XMLNameToString(name xml.Name) string {
 	if name.Space == "" {
@@ -53,13 +43,10 @@ func XMLNameMatch(n1 xml.Name, n2 xml.Name) bool {
 		(n1.Space == n2.Space || n1.Space == "")
 }
 
-// Function to register a UI element type
+// Function to register a UI element
+// type in the default registry
 func Register(name xml.Name, reflectType reflect.Type, factory Factory) {
-	// Add the factory to the map
-	elementTypes[name] = elementType{
-		ReflectType: reflectType,
-		Factory:     factory,
-	}
+	defaultRegistry.Register(name, reflectType, factory)
 }
 
 // Function to get an element's name.
@@ -96,41 +83,12 @@ func FullName(e Element, sep string, includeNamespace bool) (name string) {
 }
 
 // Function to create an element with the
-// given XML name. If none is found and
-// name.Space is empty, the function will
-// search for an element type with a
-// matching name.Local and with a matching
-// name.Space in parent.GetNamespaces(),
-// then if still not found will search for
-// an element type with a matching name.Local
-func New(fs http.FileSystem, name xml.Name, parent Layout) Element {
-	// Try to get the element type
-	elemType, ok := elementTypes[name]
-	// If it was found, call the type's factory
-	if ok {
-		return elemType.Factory(fs, name, parent)
-		// If it wasn't found, but the namespace is empty
-	} else if name.Space == "" {
-		if parent != nil {
-			// Iterate over the parent's namespaces
-			for _, ns := range parent.GetNamespaces() {
-				elemType, ok = elementTypes[xml.Name{
-					Local: name.Local, Space: ns}]
-				if ok {
-					return elemType.Factory(fs, name, parent)
-				}
-			}
-		}
-
-		// Iterate over the element types
-		for elemTypeName, elemType := range elementTypes {
-			// If the local name is a match
-			if elemTypeName.Local == name.Local {
-				// Assume that it's a match and call
-				// the type's factory
-				return elemType.Factory(fs, name, parent)
-			}
-		}
+// given XML name, using the parent's registry
+// (or the default registry, if parent is nil).
+// See Registry.New
+func New(fs http.FileSystem, name xml.Name, parent Layout) (Element, error) {
+	if parent != nil {
+		return parent.GetRegistry().New(fs, name, parent)
 	}
-	return nil
+	return defaultRegistry.New(fs, name, parent)
 }
@@ -0,0 +1,38 @@
+package element
+
+import "time"
+
+// Interface type for an element that
+// changes over time (e.g. an animation
+// or a timer), which is updated before
+// every frame is drawn
+type Updater interface {
+	// Function to update the element, given
+	// the time since the last frame. The
+	// element should invalidate whatever
+	// changed (e.g. with InvalidatePaint)
+	Update(dt time.Duration)
+}
+
+// Function to update every element in the
+// given element tree that's an Updater (see
+// Updater), given the time since the last
+// frame. Returns whether any were, as only
+// then do frames need drawing without input
+func UpdateFrame(e Element, dt time.Duration) (updated bool) {
+	updater, ok := e.(Updater)
+	if ok {
+		updater.Update(dt)
+		updated = true
+	}
+	// If it's a layout, update the children
+	layout, ok := e.(Layout)
+	if ok {
+		for i := 0; i < layout.NumChildren(); i++ {
+			if UpdateFrame(layout.GetChild(i), dt) {
+				updated = true
+			}
+		}
+	}
+	return updated
+}
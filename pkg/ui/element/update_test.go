@@ -0,0 +1,71 @@
+package element_test
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"testing"
+	"time"
+)
+
+// Type for a layout of test elements
+type testLayout struct {
+	element.Impl
+	element.LayoutImpl
+}
+
+func (e *testLayout) ResetPosition() {
+	e.Impl.ResetPosition()
+	e.LayoutImpl.ResetPosition()
+}
+
+func (e *testLayout) Reset() {
+	e.Impl.Reset()
+	e.LayoutImpl.Reset()
+}
+
+// Type for an element that records
+// the times it's updated with
+type testUpdater struct {
+	element.Impl
+	updates []time.Duration
+}
+
+func (e *testUpdater) Update(dt time.Duration) { e.updates = append(e.updates, dt) }
+
+func TestUpdateFrame(t *testing.T) {
+	tests := []struct {
+		name string
+		// The tree, and the updaters in it
+		root     func() (element.Element, []*testUpdater)
+		updating bool
+	}{
+		{"no updaters", func() (element.Element, []*testUpdater) {
+			return &testLayout{LayoutImpl: element.LayoutImpl{
+				Children: []element.Element{&element.Impl{}}}}, nil
+		}, false},
+		{"root updater", func() (element.Element, []*testUpdater) {
+			u := &testUpdater{}
+			return u, []*testUpdater{u}
+		}, true},
+		{"nested updaters", func() (element.Element, []*testUpdater) {
+			a, b := &testUpdater{}, &testUpdater{}
+			return &testLayout{LayoutImpl: element.LayoutImpl{Children: []element.Element{
+				a, &element.Impl{},
+				&testLayout{LayoutImpl: element.LayoutImpl{Children: []element.Element{b}}},
+			}}}, []*testUpdater{a, b}
+		}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, updaters := test.root()
+			dt := 16 * time.Millisecond
+			if got := element.UpdateFrame(root, dt); got != test.updating {
+				t.Errorf("got updating %t, want %t", got, test.updating)
+			}
+			for i, u := range updaters {
+				if len(u.updates) != 1 || u.updates[0] != dt {
+					t.Errorf("updater %d: got updates %v, want [%v]", i, u.updates, dt)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package element
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+)
+
+// Type for the state of loading a design
+// (shared by the files it imports)
+type loadState struct {
+	// Whether problems should be collected
+	// rather than stopping the load
+	validating bool
+	// The problems found
+	errs []error
+}
+
+// Type for an error with every
+// problem found in a design
+type LoadErrors struct {
+	// The problems, which are
+	// usually LoadErrors
+	Errors []error
+}
+
+// Function to return the error string,
+// with one problem per line
+func (e LoadErrors) Error() string {
+	strs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		strs[i] = err.Error()
+	}
+	return strings.Join(strs, "\n")
+}
+
+// Function to get the problems (so
+// errors.As can find any of them)
+func (e LoadErrors) Unwrap() []error { return e.Errors }
+
+// Interface type for an element that
+// can check for problems that aren't
+// found when it's loaded
+type Validator interface {
+	// Function to check the element,
+	// returning the first problem
+	Validate() error
+}
+
+// Function to get the load state of
+// the file the given element is in
+// (or nil, if it isn't known)
+func loadStateOf(e Element) *loadState {
+	holder, ok := sourceHolderOf(e)
+	if !ok {
+		return nil
+	}
+	file, _ := holder.getSource()
+	if file == nil {
+		return nil
+	}
+	return file.load
+}
+
+// Function to report a problem with the
+// given element while it's being loaded.
+// When validating, the problem is collected
+// (with the element's position) and nil is
+// returned so the load carries on. Otherwise
+// the error is returned
+func ReportLoadError(err error, e Element) error {
+	if err == nil {
+		return nil
+	}
+	state := loadStateOf(e)
+	if state != nil && state.validating {
+		state.errs = append(state.errs, WrapLoadError(err, e))
+		return nil
+	}
+	return err
+}
+
+// Function to report a problem creating a
+// child of the given parent (whose start
+// element the decoder has just read), like
+// ReportLoadError. If nil is returned, the
+// child should be skipped
+func ReportChildLoadError(err error, parent Element, d *xml.Decoder) error {
+	if err == nil {
+		return nil
+	}
+	state := loadStateOf(parent)
+	if state != nil && state.validating {
+		state.errs = append(state.errs, WrapChildLoadError(err, parent, d))
+		return nil
+	}
+	return err
+}
+
+// Function to load the design at the given
+// path (with the given registry, or the
+// default registry if nil) without stopping
+// at the first problem, then check the loaded
+// elements (with Validator and their callbacks).
+// Returns a LoadErrors with every problem
+// found, or nil if there weren't any
+func ValidateDesign(fs http.FileSystem, path string, registry *Registry) error {
+	state := &loadState{validating: true}
+	root, err := newRoot(fs, nil, path, registry, state)
+	if err != nil {
+		// The load couldn't carry on
+		state.errs = append(state.errs, err)
+	} else {
+		// Recursive function to check an element
+		var check func(Element)
+		check = func(e Element) {
+			validator, ok := e.(Validator)
+			if ok {
+				_ = ReportLoadError(validator.Validate(), e)
+			}
+			// If it's a layout, check the children
+			layout, ok := e.(Layout)
+			if ok {
+				for i := 0; i < layout.NumChildren(); i++ {
+					check(layout.GetChild(i))
+				}
+			}
+		}
+		check(root.Element)
+
+		// Check the callbacks
+		_ = ValidateCallbacks(root.Element, root.registry.Callbacks())
+	}
+
+	if len(state.errs) > 0 {
+		return LoadErrors{state.errs}
+	}
+	return nil
+}
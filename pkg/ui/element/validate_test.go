@@ -0,0 +1,99 @@
+package element_test
+
+import (
+	"errors"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidateDesignCallbacks(t *testing.T) {
+	design := `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">
+		<ImageButton builtin:width="10px" builtin:height="10px" builtin:background="#FF0000"
+			builtin:press-callback="save"/>
+	</LinearLayout>`
+	fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(design)}})
+
+	tests := []struct {
+		name      string
+		callbacks []string
+		// The unknown callback (or
+		// "", if it's valid)
+		unknown string
+	}{
+		{"unregistered", nil, "save"},
+		{"registered", []string{"save"}, ""},
+		{"other callback registered", []string{"load"}, "save"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry := element.NewRegistry(element.DefaultRegistry())
+			for _, name := range test.callbacks {
+				registry.RegisterCallback(name, func(*element.CallbackContext) error { return nil })
+			}
+
+			err := element.ValidateDesign(fs, "design.xml", registry)
+			var unknownErr element.UnknownCallbackError
+			if test.unknown == "" {
+				if err != nil {
+					t.Errorf("got error %v, want none", err)
+				}
+			} else if !errors.As(err, &unknownErr) {
+				t.Errorf("got error %v, want an UnknownCallbackError", err)
+			} else if unknownErr.Callback != test.unknown {
+				t.Errorf("got unknown callback '%s', want '%s'", unknownErr.Callback, test.unknown)
+			}
+
+			// Which is the same as checking the loaded design
+			root, err := element.NewRoot(fs, nil, "design.xml", registry)
+			if err != nil {
+				t.Fatalf("error loading design: %v", err)
+			}
+			err = element.ValidateCallbacks(root.Element, registry.Callbacks())
+			if (err == nil) != (test.unknown == "") {
+				t.Errorf("got callback error %v, want one: %t", err, test.unknown != "")
+			}
+		})
+	}
+}
+
+func TestValidateNoContent(t *testing.T) {
+	tests := []struct {
+		name string
+		elem string
+	}{
+		{"Image", `<Image builtin:width="match_content" builtin:height="10px"/>`},
+		{"Text", `<Text builtin:width="10px" builtin:height="match_content"/>`},
+		{"ImageButton", `<ImageButton builtin:width="match_content" builtin:height="10px"/>`},
+		{"TextButton", `<TextButton builtin:width="10px" builtin:height="match_content"/>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			design := `<LinearLayout ` + ns + ` builtin:width="match_parent" builtin:height="match_parent">` +
+				test.elem + `</LinearLayout>`
+			fs := http.FS(fstest.MapFS{"design.xml": {Data: []byte(design)}})
+
+			// Validating the design finds the problem
+			err := element.ValidateDesign(fs, "design.xml", nil)
+			var loadErrs element.LoadErrors
+			if !errors.As(err, &loadErrs) || len(loadErrs.Errors) != 1 {
+				t.Fatalf("got error %v, want one problem", err)
+			}
+			want := "no content to match"
+			if !strings.HasSuffix(loadErrs.Errors[0].Error(), want) {
+				t.Errorf("got problem %v, want %q", loadErrs.Errors[0], want)
+			}
+
+			// And so does laying it out
+			root := loadDesign(t, design)
+			window := pixel.R(0, 0, 100, 100)
+			_, err = element.LayoutUI(root, window, &window)
+			if err == nil || !strings.HasSuffix(err.Error(), want) {
+				t.Errorf("got layout error %v, want %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/faiface/pixel"
+	"log"
+)
+
+// How many errors the errors channel
+// holds before they're logged instead
+const errorsBufferSize = 16
+
+// Function to close the design's window,
+// stopping its run loop after the current
+// frame. It's safe to call from any goroutine
+// (including callbacks) and more than once.
+// Use Wait to wait for the loop to stop
+func (d *Design) Close() {
+	d.closeOnce.Do(func() { close(d.quit) })
+
+	// If the run loop isn't running,
+	// there's nothing to stop
+	if !d.isRunning() {
+		d.destroyOnce.Do(d.window.Destroy)
+	} else {
+		// Stop it waiting for input
+		d.window.Wake()
+	}
+}
+
+// Function to add a function that's called
+// (with the design locked) when the design
+// closes, because its window was closed, Close
+// was called or Start's context was cancelled
+func (d *Design) OnClose(f func()) {
+	d.Lock()
+	defer d.Unlock()
+	d.closeHandlers = append(d.closeHandlers, f)
+}
+
+// Function to add a function that's called
+// (with the design locked) when the design's
+// window is resized, after the design has
+// been laid out for the new bounds
+func (d *Design) OnResize(f func(bounds pixel.Rect)) {
+	d.Lock()
+	defer d.Unlock()
+	d.resizeHandlers = append(d.resizeHandlers, f)
+}
+
+// Function to get the errors from the design's
+// run loop (e.g. if the design couldn't be laid
+// out). The channel is closed when the loop
+// stops. If it isn't read from, the errors that
+// don't fit in its buffer are logged instead
+func (d *Design) Errors() <-chan error { return d.errs }
+
+// Function to report an error from
+// the design's run loop, without
+// waiting for it to be read
+func (d *Design) reportError(err error) {
+	select {
+	case d.errs <- err:
+	default:
+		log.Printf("Error in XML design: %+v", err)
+	}
+}
+
+// Function to call the given function,
+// reporting a panic as an error rather
+// than letting it stop the program
+func (d *Design) recoverPanic(what string, f func()) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			d.reportError(fmt.Errorf("panic in %s: %v", what, r))
+		}
+	}()
+	f()
+}
@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"github.com/faiface/pixel"
+	"testing"
+	"time"
+)
+
+func TestReportError(t *testing.T) {
+	d := newTestDesign()
+	// Reporting more errors than the buffer
+	// holds doesn't wait for them to be read
+	for i := 0; i < errorsBufferSize+5; i++ {
+		d.reportError(errors.New("test error"))
+	}
+	if len(d.Errors()) != errorsBufferSize {
+		t.Errorf("got %d buffered errors, want %d", len(d.Errors()), errorsBufferSize)
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func()
+		err  string
+	}{
+		{"no panic", func() {}, ""},
+		{"panic", func() { panic("oops") }, "panic in the test: oops"},
+		{"error panic", func() { panic(errors.New("oops")) }, "panic in the test: oops"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newTestDesign()
+			d.recoverPanic("the test", test.f)
+			select {
+			case err := <-d.Errors():
+				if err.Error() != test.err {
+					t.Errorf("got error %q, want %q", err, test.err)
+				}
+			default:
+				if test.err != "" {
+					t.Errorf("no error was reported, want %q", test.err)
+				}
+			}
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	d := newTestDesign()
+
+	// If the design isn't running,
+	// it doesn't wait
+	d.Wait()
+
+	// Otherwise it waits for the loop to stop
+	d.setRunning(true)
+	waited := make(chan struct{})
+	go func() {
+		d.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while the design was running")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	d.Lock()
+	d.setRunning(false)
+	d.Unlock()
+	d.waitCondVar.Broadcast()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("Wait didn't return after the design stopped")
+	}
+}
+
+func TestClose(t *testing.T) {
+	d := newTestDesign()
+	d.setRunning(true)
+
+	// It can be called more than once
+	d.Close()
+	d.Close()
+	select {
+	case <-d.quit:
+	default:
+		t.Fatal("Close didn't stop the run loop")
+	}
+	if !d.isClosing(context.Background()) {
+		t.Errorf("the design isn't closing after Close")
+	}
+}
+
+func TestStartContextWhenClosing(t *testing.T) {
+	tests := []struct {
+		name string
+		// Function to stop the design
+		// (returning the context to start it with)
+		stop func(d *Design) context.Context
+	}{
+		{"closed", func(d *Design) context.Context {
+			d.closeOnce.Do(func() { close(d.quit) })
+			return context.Background()
+		}},
+		{"cancelled", func(d *Design) context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newTestDesign()
+			ctx := test.stop(d)
+			if !d.isClosing(ctx) {
+				t.Errorf("the design isn't closing")
+			}
+			// The run loop isn't started
+			d.StartContext(ctx)
+			if d.isRunning() {
+				t.Errorf("the run loop was started")
+			}
+		})
+	}
+}
+
+func TestOnClose(t *testing.T) {
+	tests := []struct {
+		name string
+		// Function to start then close the design
+		run func(d *Design)
+	}{
+		{"Close", func(d *Design) { d.Start(); d.Close() }},
+		// With the design locked
+		{"Close from a posted function", func(d *Design) { d.Start(); d.Post(d.Close) }},
+		{"context cancelled", func(d *Design) {
+			ctx, cancel := context.WithCancel(context.Background())
+			d.StartContext(ctx)
+			cancel()
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, _ := newLoadedTestDesign(t)
+			closed := 0
+			d.OnClose(func() { closed++ })
+			within(t, "closing the design", func() {
+				test.run(d)
+				d.Wait()
+			})
+			if closed != 1 {
+				t.Errorf("the OnClose function was called %d times, want once", closed)
+			}
+			// The loop stopped
+			if _, ok := <-d.Errors(); ok {
+				t.Errorf("the errors channel wasn't closed")
+			}
+			if err := d.Do(func() error { return nil }); err != ErrStopped {
+				t.Errorf("got error %v from Do, want ErrStopped", err)
+			}
+		})
+	}
+}
+
+func TestOnResize(t *testing.T) {
+	d, window := newLoadedTestDesign(t)
+	var resizes []pixel.Rect
+	d.OnResize(func(bounds pixel.Rect) { resizes = append(resizes, bounds) })
+	d.Start()
+	defer within(t, "closing the design", func() { d.Close(); d.Wait() })
+
+	// Function to wait for a frame, calling
+	// the given function at the end of it
+	frame := func(name string, f func()) {
+		t.Helper()
+		within(t, name, func() {
+			_ = d.Do(func() error { f(); return nil })
+		})
+	}
+
+	// Nothing is resized without a change
+	frame("a frame", func() {})
+	frame("a frame", func() {
+		if len(resizes) != 0 {
+			t.Errorf("got resizes %v before the window was resized, want none", resizes)
+		}
+	})
+
+	// The next frame sees the window's new bounds
+	bounds := pixel.R(0, 0, 200, 150)
+	frame("resizing", func() { window.SetBounds(bounds) })
+	frame("the frame after resizing", func() {
+		if len(resizes) != 1 || resizes[0] != bounds {
+			t.Errorf("got resizes %v, want [%v]", resizes, bounds)
+		}
+		// After the design was laid out again
+		if got := d.Root().GetBounds(); got == nil || *got != bounds {
+			t.Errorf("got root bounds %v, want %v", got, bounds)
+		}
+	})
+	frame("a frame", func() {
+		if len(resizes) != 1 {
+			t.Errorf("got resizes %v after the window stayed the same, want one", resizes)
+		}
+	})
+}
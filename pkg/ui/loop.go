@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"context"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"time"
+)
+
+// Type for how a design's run
+// loop decides when to draw frames
+type RunMode int
+
+const (
+	// Frames are only drawn when something
+	// changed (e.g. because of input or a
+	// posted function), and the loop sleeps
+	// until then. If the design has elements
+	// that are updated every frame (see
+	// element.Updater), the loop wakes up
+	// at most MaxFPS times a second for them
+	EventDriven = RunMode(iota)
+	// Frames are drawn at most
+	// MaxFPS times a second
+	FixedFPS
+	// Frames are drawn in step with
+	// the display's refresh rate
+	VSync
+)
+
+// The default most frames a second
+const DefaultMaxFPS = 60
+
+// Type for the configuration
+// of a design's run loop
+type RunConfig struct {
+	// When frames are drawn
+	Mode RunMode
+	// The most frames a second (or 0
+	// for DefaultMaxFPS). Not used by VSync
+	MaxFPS float64
+}
+
+// Function to get the time
+// between frames at the most
+// frames a second
+func (c RunConfig) frameTime() time.Duration {
+	fps := c.MaxFPS
+	if fps <= 0 {
+		fps = DefaultMaxFPS
+	}
+	return time.Duration(float64(time.Second) / fps)
+}
+
+// Function to set the configuration
+// of the design's run loop, which is
+// used from the next frame
+func (d *Design) SetRunConfig(config RunConfig) {
+	d.Lock()
+	d.runConfig = config
+	d.Unlock()
+	// Don't wait for input with the old config
+	d.window.Wake()
+}
+
+// Function to get the configuration
+// of the design's run loop
+func (d *Design) RunConfig() RunConfig {
+	d.Lock()
+	defer d.Unlock()
+	return d.runConfig
+}
+
+// Interface type for the parts of the
+// window the run loop uses (so it can
+// run without a display in tests)
+type loopWindow interface {
+	Bounds() pixel.Rect
+	Closed() bool
+	Focused() bool
+	Destroy()
+	SetVSync(vsync bool)
+	SwapBuffers()
+	// Function to update the input
+	// without waiting for events
+	UpdateInput()
+	// Function to wait for an event (or
+	// Wake) for at most the given time (or
+	// for as long as it takes, if it's 0),
+	// then update the input
+	UpdateInputWait(timeout time.Duration)
+	// Function to stop UpdateInputWait
+	// waiting (or the next call, if it isn't
+	// waiting). It can be called from any
+	// goroutine
+	Wake()
+}
+
+// Type for a pixelgl window
+// the run loop can use
+type pixelWindow struct {
+	*pixelgl.Window
+}
+
+// Function to wake the run loop up, by
+// posting an empty event. Unlike the
+// window's other functions, GLFW lets
+// this be called from any goroutine
+// (and it mustn't wait for the main
+// thread, which is waiting for events)
+func (w pixelWindow) Wake() { glfw.PostEmptyEvent() }
+
+// Function to run the design's loop until
+// the window is closed, Close is called or
+// the given context is cancelled
+func (d *Design) run(ctx context.Context) {
+	// Whether the window uses vsync
+	var vsync bool
+	// When the last frame was
+	lastFrame := time.Now()
+	// Whether any elements are updated
+	// every frame (so the loop can't
+	// just wait for input)
+	updating := false
+
+	// Wake the loop up if the context is
+	// cancelled (Close wakes it up itself)
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.window.Wake()
+		case <-d.quit:
+		}
+	}()
+
+	// While the design is still open
+	for !d.isClosing(ctx) {
+		config := d.RunConfig()
+		if vsync != (config.Mode == VSync) {
+			vsync = config.Mode == VSync
+			d.window.SetVSync(vsync)
+		}
+
+		// Wait for the next frame
+		switch config.Mode {
+		case EventDriven:
+			// Wait for a new event (posting something
+			// wakes the loop up), or for the next frame
+			// if there are elements that are updated
+			switch {
+			case updating:
+				wait := config.frameTime() - time.Since(lastFrame)
+				if wait > 0 {
+					d.window.UpdateInputWait(wait)
+				} else {
+					d.window.UpdateInput()
+				}
+			case d.hasPosted():
+				d.window.UpdateInput()
+			default:
+				d.window.UpdateInputWait(0)
+			}
+		case FixedFPS:
+			// Wait until it's time for the frame
+			wait := config.frameTime() - time.Since(lastFrame)
+			if wait > 0 {
+				time.Sleep(wait)
+			}
+			d.window.UpdateInput()
+		case VSync:
+			// The last frame waited for the display
+			d.window.UpdateInput()
+		}
+
+		// Get the time since the last frame
+		now := time.Now()
+		dt := now.Sub(lastFrame)
+		lastFrame = now
+
+		// Do the frame
+		var drawn bool
+		drawn, updating = d.frame(dt)
+
+		// If nothing was drawn, swap the buffers
+		// anyway so the loop waits for the display
+		if vsync && !drawn {
+			d.window.SwapBuffers()
+		}
+	}
+
+	d.Lock()
+	// Call anything still posted
+	d.stopPosted()
+	// Tell anything waiting for the design to close
+	for _, f := range d.closeHandlers {
+		d.recoverPanic("an OnClose function", f)
+	}
+	d.setRunning(false)
+	d.Unlock()
+
+	// Stop anything else (e.g. the
+	// watcher) and get rid of the window
+	d.closeOnce.Do(func() { close(d.quit) })
+	d.destroyOnce.Do(d.window.Destroy)
+	close(d.errs)
+
+	// Broadcast to any threads
+	// waiting for the design to close
+	d.waitCondVar.Broadcast()
+}
+
+// Function to do a frame of the design's
+// run loop, given the time since the last
+// frame. Returns whether anything was drawn,
+// and whether any elements are updated every
+// frame. Errors (and panics) are sent to the
+// errors channel
+func (d *Design) frame(dt time.Duration) (drawn, updating bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.recoverPanic("the design's run loop", func() {
+		// If the window bounds changed
+		if d.prevWindowBounds != d.window.Bounds() {
+			// Update the design
+			err := d.update(d.root)
+			if err != nil {
+				d.reportError(err)
+			} else {
+				for _, f := range d.resizeHandlers {
+					f(d.prevWindowBounds)
+				}
+			}
+		}
+
+		// Call anything posted from other goroutines
+		d.runPosted()
+
+		// Send the events to the elements
+		if d.window.Focused() {
+			d.events.Poll(d.root.Element, d.renderer)
+		}
+
+		// Update the elements for the frame
+		updating = element.UpdateFrame(d.root.Element, dt)
+
+		// Lay out anything that was invalidated
+		err := element.UpdateUI(d.root.Element, d.renderer, &d.prevWindowBounds)
+		if err != nil {
+			d.reportError(err)
+		}
+
+		// Draw anything that changed
+		drawn = d.root.Element.NeedsPaint()
+		element.DrawUI(d.root.Element, d.output())
+	})
+	return drawn, updating
+}
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/render/software"
+	"github.com/faiface/pixel"
+	"net/http"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// Type for a window the run loop can
+// use without a display, which draws with
+// the software renderer and has no input
+type testWindow struct {
+	*software.Renderer
+	// Sent to when the window is woken up
+	woken chan struct{}
+	// The timeouts UpdateInputWait was
+	// called with, and the mutex
+	// that guards them
+	waits     []time.Duration
+	waitsLock sync.Mutex
+}
+
+// Function to create a test
+// window with the given bounds
+func newTestWindow(bounds pixel.Rect) *testWindow {
+	return &testWindow{
+		Renderer: software.NewRenderer(bounds),
+		woken:    make(chan struct{}, 1),
+	}
+}
+
+func (w *testWindow) Closed() bool                          { return false }
+func (w *testWindow) Focused() bool                         { return true }
+func (w *testWindow) Destroy()                              {}
+func (w *testWindow) SetVSync(bool)                         {}
+func (w *testWindow) SwapBuffers()                          {}
+func (w *testWindow) UpdateInput()                          {}
+func (w *testWindow) MouseInsideWindow() bool               { return false }
+func (w *testWindow) MousePosition() pixel.Vec              { return pixel.ZV }
+func (w *testWindow) MouseScroll() pixel.Vec                { return pixel.ZV }
+func (w *testWindow) MousePressed(element.MouseButton) bool { return false }
+func (w *testWindow) KeyPressed(element.Key) bool           { return false }
+func (w *testWindow) Typed() string                         { return "" }
+
+func (w *testWindow) UpdateInputWait(timeout time.Duration) {
+	w.waitsLock.Lock()
+	w.waits = append(w.waits, timeout)
+	w.waitsLock.Unlock()
+	if timeout == 0 {
+		<-w.woken
+		return
+	}
+	select {
+	case <-w.woken:
+	case <-time.After(timeout):
+	}
+}
+
+// Like GLFW's empty events, waking the window
+// up when it isn't waiting stops the next wait
+func (w *testWindow) Wake() {
+	select {
+	case w.woken <- struct{}{}:
+	default:
+	}
+}
+
+// Function to get the timeouts
+// UpdateInputWait was called with
+func (w *testWindow) timeouts() []time.Duration {
+	w.waitsLock.Lock()
+	defer w.waitsLock.Unlock()
+	return append([]time.Duration(nil), w.waits...)
+}
+
+// Function to create and initialise a design
+// of an image in a test window, which can be
+// run without a display
+func newLoadedTestDesign(t *testing.T) (*Design, *testWindow) {
+	t.Helper()
+	d := newTestDesign()
+	d.path = "design.xml"
+	d.files = newRecordingFS(http.FS(fstest.MapFS{"design.xml": {Data: []byte(
+		`<Image xmlns="http://github.com/bhollier/ui/api/schema" ` +
+			`width="match_parent" height="match_parent" source="#FF0000"/>`)}}))
+	d.fs = d.files
+	d.registry = element.NewRegistry(element.DefaultRegistry())
+	d.events = element.NewEventDispatcher(d, d.registry.Callbacks())
+	var err error
+	d.root, err = d.load()
+	if err != nil {
+		t.Fatalf("error loading design: %v", err)
+	}
+	err = d.Init()
+	if err != nil {
+		t.Fatalf("error initialising design: %v", err)
+	}
+	return d, d.window.(*testWindow)
+}
+
+// Function to call the given function,
+// failing the test if it doesn't
+// return within a second
+func within(t *testing.T, name string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s took too long", name)
+	}
+}
+
+func TestRunConfigFrameTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RunConfig
+		want   time.Duration
+	}{
+		{"default", RunConfig{}, time.Second / DefaultMaxFPS},
+		{"negative", RunConfig{MaxFPS: -1}, time.Second / DefaultMaxFPS},
+		{"30 FPS", RunConfig{Mode: FixedFPS, MaxFPS: 30}, time.Second / 30},
+		{"fractional", RunConfig{MaxFPS: 0.5}, 2 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.config.frameTime(); got != test.want {
+				t.Errorf("got frame time %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSetRunConfig(t *testing.T) {
+	d := newTestDesign()
+	if got := d.RunConfig(); got != (RunConfig{}) {
+		t.Errorf("got run config %+v, want the default", got)
+	}
+	config := RunConfig{Mode: VSync, MaxFPS: 144}
+	d.SetRunConfig(config)
+	if got := d.RunConfig(); got != config {
+		t.Errorf("got run config %+v, want %+v", got, config)
+	}
+}
+
+func TestEventDrivenLoop(t *testing.T) {
+	d, window := newLoadedTestDesign(t)
+	d.Start()
+	defer func() { within(t, "Close", func() { d.Close(); d.Wait() }) }()
+
+	// Function to check the loop only
+	// waited for input with no timeout
+	checkWaits := func(name string, want int) {
+		t.Helper()
+		// Give the loop time to wait again
+		time.Sleep(20 * time.Millisecond)
+		timeouts := window.timeouts()
+		if len(timeouts) != want {
+			t.Errorf("%s: the loop waited %d times, want %d", name, len(timeouts), want)
+		}
+		for _, timeout := range timeouts {
+			if timeout != 0 {
+				t.Errorf("%s: got timeouts %v, want them all to be 0", name, timeouts)
+				break
+			}
+		}
+	}
+	// When nothing happens, the loop
+	// only waits (it doesn't poll)
+	time.Sleep(50 * time.Millisecond)
+	checkWaits("idle", 1)
+
+	// Posting wakes it up
+	called := make(chan struct{})
+	d.Post(func() { close(called) })
+	within(t, "the posted function", func() { <-called })
+	checkWaits("posted", 2)
+	within(t, "Do", func() {
+		err := d.Do(func() error { return nil })
+		if err != nil {
+			t.Errorf("got error %v from Do, want none", err)
+		}
+	})
+	checkWaits("done", 3)
+
+	// As does changing the run config
+	d.SetRunConfig(RunConfig{Mode: EventDriven, MaxFPS: 30})
+	checkWaits("run config changed", 4)
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// The most lines of an
+// error the overlay shows
+const overlayMaxLines = 12
+
+// The space around the
+// overlay's text, in pixels
+const overlayPadding = 8
+
+// The overlay's colours
+var (
+	overlayBkgColor  = color.RGBA{R: 0x80, G: 0x10, B: 0x10, A: 0xFF}
+	overlayTextColor = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+)
+
+// Type for a renderer that draws an
+// error (e.g. from reloading the design)
+// across the top of everything it presents
+type errorOverlay struct {
+	element.Renderer
+
+	// The error's lines
+	lines []string
+	// The overlay's surface, and the
+	// bounds of the renderer it was
+	// created for
+	surface        element.Surface
+	rendererBounds pixel.Rect
+	// The surface the design and the
+	// overlay are drawn onto together
+	composite element.Surface
+}
+
+// Function to create an overlay for
+// the given error, which draws onto
+// the given renderer
+func newErrorOverlay(r element.Renderer, err error) *errorOverlay {
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) > overlayMaxLines {
+		lines = append(lines[:overlayMaxLines-1], "...")
+	}
+	return &errorOverlay{Renderer: r, lines: lines}
+}
+
+// Function to draw the overlay's
+// surface, if the renderer's
+// bounds have changed
+func (o *errorOverlay) draw() {
+	bounds := o.Bounds()
+	if o.surface != nil && o.rendererBounds == bounds {
+		return
+	}
+	o.rendererBounds = bounds
+
+	// Work out the overlay's bounds,
+	// across the top of the renderer
+	var face font.Face = basicfont.Face7x13
+	metrics := face.Metrics()
+	lineHeight := float64(metrics.Height.Ceil())
+	height := math.Min(bounds.H(), lineHeight*float64(len(o.lines))+2*overlayPadding)
+	surfaceBounds := pixel.R(bounds.Min.X, bounds.Max.Y-height, bounds.Max.X, bounds.Max.Y)
+	if o.surface == nil {
+		o.surface = o.NewSurface(surfaceBounds)
+	} else {
+		o.surface.SetBounds(surfaceBounds)
+	}
+
+	// Draw the background
+	o.surface.SetClip(nil)
+	o.surface.Clear(overlayBkgColor)
+	// Draw the error, starting
+	// with the first line's baseline
+	for i, line := range o.lines {
+		o.surface.DrawText(line, face, overlayTextColor, pixel.IM.Moved(pixel.V(
+			surfaceBounds.Min.X+overlayPadding,
+			surfaceBounds.Max.Y-overlayPadding-float64(metrics.Ascent.Ceil())-
+				lineHeight*float64(i))))
+	}
+}
+
+// Function to draw the given surface
+// with the overlay over it onto the
+// renderer and display it
+func (o *errorOverlay) Present(s element.Surface, damage []pixel.Rect) {
+	o.draw()
+
+	// Draw them together
+	if o.composite == nil {
+		o.composite = o.NewSurface(o.rendererBounds)
+	} else if o.composite.Bounds() != o.rendererBounds {
+		o.composite.SetBounds(o.rendererBounds)
+	}
+	o.composite.SetClip(nil)
+	o.composite.Clear(color.Transparent)
+	o.composite.DrawSurface(s)
+	o.composite.DrawSurface(o.surface)
+
+	// The overlay always needs drawing
+	if damage != nil {
+		damage = append(append([]pixel.Rect{}, damage...), o.surface.Bounds())
+	}
+	o.Renderer.Present(o.composite, damage)
+}
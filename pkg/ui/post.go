@@ -0,0 +1,93 @@
+package ui
+
+import "errors"
+
+// The error Do returns when the design
+// has stopped, so the function can't
+// be called between frames
+var ErrStopped = errors.New("the design has stopped")
+
+// The error Do returns when the
+// function panicked (the panic is
+// sent to the design's errors)
+var errPanicked = errors.New("the function panicked")
+
+// Function to queue a function to be called
+// between frames, with the design locked.
+// This is the safe way to change the design's
+// elements (e.g. Text.SetText) from other
+// goroutines. It doesn't wait for the
+// function to be called, and does nothing
+// if the design has stopped
+func (d *Design) Post(f func()) {
+	d.post(f)
+}
+
+// Function to call a function between frames,
+// with the design locked (like Post), waiting
+// for it to finish. Returns the function's error
+// (or an error if it panicked), or ErrStopped if
+// the design has stopped. It
+// mustn't be called from the design's own
+// goroutine (e.g. from a callback, which can
+// change the elements directly), as it would
+// wait forever
+func (d *Design) Do(f func() error) error {
+	done := make(chan error, 1)
+	if !d.post(func() {
+		err := errPanicked
+		defer func() { done <- err }()
+		err = f()
+	}) {
+		return ErrStopped
+	}
+	return <-done
+}
+
+// Function to queue a function to be
+// called between frames, waking the run
+// loop up for it. Returns false if the
+// design has stopped
+func (d *Design) post(f func()) bool {
+	d.postedLock.Lock()
+	if d.stopped {
+		d.postedLock.Unlock()
+		return false
+	}
+	d.posted = append(d.posted, f)
+	d.postedLock.Unlock()
+	d.window.Wake()
+	return true
+}
+
+// Function to determine whether
+// any functions are queued
+func (d *Design) hasPosted() bool {
+	d.postedLock.Lock()
+	defer d.postedLock.Unlock()
+	return len(d.posted) > 0
+}
+
+// Function to call the queued functions
+// (in the order they were posted). The
+// design must be locked
+func (d *Design) runPosted() {
+	d.postedLock.Lock()
+	posted := d.posted
+	d.posted = nil
+	d.postedLock.Unlock()
+
+	for _, f := range posted {
+		d.recoverPanic("a posted function", f)
+	}
+}
+
+// Function to stop calling queued functions,
+// calling any that are left (so nothing waits
+// for them forever). The design must be locked
+func (d *Design) stopPosted() {
+	d.postedLock.Lock()
+	d.stopped = true
+	d.postedLock.Unlock()
+	d.runPosted()
+}
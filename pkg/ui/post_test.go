@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"errors"
+	"github.com/faiface/pixel"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Function to create a design without
+// elements (with a test window), for
+// testing what doesn't need them
+func newTestDesign() *Design {
+	window := newTestWindow(pixel.R(0, 0, 100, 100))
+	d := &Design{
+		window:   window,
+		renderer: window,
+		quit:     make(chan struct{}),
+		errs:     make(chan error, errorsBufferSize),
+	}
+	d.waitCondVar = sync.NewCond(d)
+	return d
+}
+
+// Function to call the design's posted
+// functions until the given channel is
+// closed, like the run loop does
+func runPostedUntil(d *Design, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Millisecond):
+		}
+		d.Lock()
+		d.runPosted()
+		d.Unlock()
+	}
+}
+
+func TestPostOrder(t *testing.T) {
+	d := newTestDesign()
+	var calls []int
+	for i := 0; i < 3; i++ {
+		i := i
+		d.Post(func() {
+			calls = append(calls, i)
+			// Posting from a posted function
+			// waits for the next frame
+			d.Post(func() { calls = append(calls, 10+i) })
+		})
+	}
+
+	tests := []struct {
+		name string
+		want []int
+	}{
+		{"in the order they were posted", []int{0, 1, 2}},
+		{"posted while running", []int{0, 1, 2, 10, 11, 12}},
+		{"nothing left", []int{0, 1, 2, 10, 11, 12}},
+	}
+	for _, test := range tests {
+		d.Lock()
+		d.runPosted()
+		d.Unlock()
+		if len(calls) != len(test.want) {
+			t.Fatalf("%s: got calls %v, want %v", test.name, calls, test.want)
+		}
+		for i := range calls {
+			if calls[i] != test.want[i] {
+				t.Fatalf("%s: got calls %v, want %v", test.name, calls, test.want)
+			}
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTest := errors.New("test error")
+	tests := []struct {
+		name string
+		f    func() error
+		want error
+		// Whether an error is reported
+		// on the design's errors
+		reported bool
+	}{
+		{"success", func() error { return nil }, nil, false},
+		{"error", func() error { return errTest }, errTest, false},
+		{"panic", func() error { panic("test panic") }, errPanicked, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newTestDesign()
+			done := make(chan struct{})
+			defer close(done)
+			go runPostedUntil(d, done)
+
+			err := d.Do(test.f)
+			if err != test.want {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+			select {
+			case err := <-d.errs:
+				if !test.reported {
+					t.Errorf("got reported error %v, want none", err)
+				}
+			default:
+				if test.reported {
+					t.Errorf("no error was reported")
+				}
+			}
+		})
+	}
+}
+
+func TestDoWaitsForTheFunction(t *testing.T) {
+	d := newTestDesign()
+	called := false
+	result := make(chan error)
+	go func() {
+		result <- d.Do(func() error {
+			called = true
+			return nil
+		})
+	}()
+
+	// It isn't called until between frames
+	select {
+	case err := <-result:
+		t.Fatalf("Do returned %v before the function was called", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go runPostedUntil(d, done)
+	if err := <-result; err != nil || !called {
+		t.Errorf("got error %v (called: %t), want the function to be called", err, called)
+	}
+}
+
+func TestStopped(t *testing.T) {
+	d := newTestDesign()
+
+	// Functions waiting when the design stops
+	// are still called, so Do doesn't wait forever
+	result := make(chan error)
+	go func() { result <- d.Do(func() error { return nil }) }()
+	for {
+		d.postedLock.Lock()
+		n := len(d.posted)
+		d.postedLock.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	d.Lock()
+	d.stopPosted()
+	d.Unlock()
+	if err := <-result; err != nil {
+		t.Errorf("got error %v from the waiting Do, want none", err)
+	}
+
+	// But once it's stopped nothing is queued
+	if err := d.Do(func() error { return nil }); err != ErrStopped {
+		t.Errorf("got error %v, want ErrStopped", err)
+	}
+	d.Post(func() { t.Errorf("posted function called after the design stopped") })
+	if len(d.posted) != 0 {
+		t.Errorf("got %d posted functions, want none", len(d.posted))
+	}
+}
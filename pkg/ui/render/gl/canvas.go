@@ -0,0 +1,122 @@
+package gl
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/mainthread"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"golang.org/x/image/font"
+	"image/color"
+	"math"
+)
+
+// Type for an element.Surface
+// that draws onto a pixelgl canvas
+type Canvas struct {
+	// The window that created
+	// the canvas
+	window *Window
+
+	// The pixelgl canvas itself
+	canvas *pixelgl.Canvas
+
+	// The rectangle drawing is restricted
+	// to (or nil, if it isn't)
+	clip *pixel.Rect
+}
+
+// Function to get the pixelgl canvas
+func (c *Canvas) PixelCanvas() *pixelgl.Canvas { return c.canvas }
+
+// Function to get the canvas' bounds
+func (c *Canvas) Bounds() pixel.Rect { return c.canvas.Bounds() }
+
+// Function to set the canvas' bounds
+func (c *Canvas) SetBounds(bounds pixel.Rect) { c.canvas.SetBounds(bounds) }
+
+// Function to restrict drawing
+// to the given rectangle of the
+// canvas (or nil for all of it)
+func (c *Canvas) SetClip(clip *pixel.Rect) {
+	if clip == nil {
+		c.clip = nil
+		return
+	}
+	c.clip = new(pixel.Rect)
+	*c.clip = *clip
+}
+
+// Function to clear the canvas
+// (or its clip) with the given colour
+func (c *Canvas) Clear(col color.Color) {
+	withClip(c.canvas, c.clip, func() { c.canvas.Clear(col) })
+}
+
+// Function to draw a sprite
+// onto the canvas
+func (c *Canvas) DrawSprite(s *pixel.Sprite, mat pixel.Matrix) {
+	withClip(c.canvas, c.clip, func() { s.Draw(c.canvas, mat) })
+}
+
+// Function to draw some text
+// onto the canvas
+func (c *Canvas) DrawText(str string, face font.Face, col color.Color, mat pixel.Matrix) {
+	// Make a new text object
+	txt := text.New(pixel.ZV, c.window.atlas(face))
+	// Set the text colour
+	txt.Color = col
+	// Write the text
+	_, _ = txt.WriteString(str)
+	// Draw it
+	withClip(c.canvas, c.clip, func() { txt.Draw(c.canvas, mat) })
+}
+
+// Function to draw another canvas
+// onto this canvas
+func (c *Canvas) DrawSurface(child element.Surface) {
+	withClip(c.canvas, c.clip, func() { child.(*Canvas).drawOnto(c.canvas) })
+}
+
+// Function to draw the canvas onto
+// a pixel target, at the canvas'
+// bounds
+func (c *Canvas) drawOnto(t pixel.Target) {
+	mat := pixel.IM
+	// Move it to where the canvas wants to be
+	mat = mat.Moved(c.canvas.Bounds().Center())
+	// Draw the canvas onto the target
+	c.canvas.Draw(t, mat)
+}
+
+// Function to call a function that
+// draws onto a pixelgl canvas, with the
+// drawing restricted to the given
+// rectangle of the canvas (or not, if
+// it's nil). The clip uses OpenGL's
+// scissor test, which applies to
+// everything drawn, so it's only
+// enabled while the function draws
+func withClip(canvas *pixelgl.Canvas, clip *pixel.Rect, draw func()) {
+	if clip == nil {
+		draw()
+		return
+	}
+
+	// Get the clip relative to the canvas'
+	// framebuffer, which starts at (0, 0)
+	bounds := canvas.Bounds()
+	x := math.Floor(clip.Min.X - bounds.Min.X)
+	y := math.Floor(clip.Min.Y - bounds.Min.Y)
+	w := math.Ceil(clip.Max.X-bounds.Min.X) - x
+	h := math.Ceil(clip.Max.Y-bounds.Min.Y) - y
+	mainthread.CallNonBlock(func() {
+		gl.Enable(gl.SCISSOR_TEST)
+		gl.Scissor(int32(x), int32(y), int32(w), int32(h))
+	})
+	draw()
+	mainthread.CallNonBlock(func() {
+		gl.Disable(gl.SCISSOR_TEST)
+	})
+}
@@ -0,0 +1,155 @@
+package gl
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/font"
+	"image/color"
+)
+
+// Type for an element.Window that
+// draws onto and gets its input
+// from a pixelgl window
+type Window struct {
+	// The pixelgl window itself
+	window *pixelgl.Window
+
+	// The text atlases, with the
+	// key being the atlas' font face
+	atlases map[font.Face]*text.Atlas
+}
+
+// Function to create a window
+// from a pixelgl window
+func NewWindow(window *pixelgl.Window) *Window {
+	return &Window{
+		window:  window,
+		atlases: make(map[font.Face]*text.Atlas, 0),
+	}
+}
+
+// Function to get the pixelgl window
+func (w *Window) PixelWindow() *pixelgl.Window { return w.window }
+
+// Function to get the window's bounds
+func (w *Window) Bounds() pixel.Rect { return w.window.Bounds() }
+
+// Function to create a new canvas
+// with the given bounds
+func (w *Window) NewSurface(bounds pixel.Rect) element.Surface {
+	return &Canvas{window: w, canvas: pixelgl.NewCanvas(bounds)}
+}
+
+// Function to draw the damaged regions
+// of the given canvas (or all of it, if
+// damage is nil) onto the window and
+// swap the window's buffers. The window
+// keeps what was drawn onto it, so the
+// rest is left as it was
+func (w *Window) Present(s element.Surface, damage []pixel.Rect) {
+	if damage == nil {
+		damage = []pixel.Rect{w.window.Canvas().Bounds()}
+	}
+	for i := range damage {
+		// Only draw onto the region
+		withClip(w.window.Canvas(), &damage[i], func() {
+			// Clear it
+			w.window.Canvas().Clear(color.Transparent)
+			// Draw the canvas onto it
+			s.(*Canvas).drawOnto(w.window.Canvas())
+		})
+	}
+	// Swap the window's buffers
+	w.window.SwapBuffers()
+}
+
+// Function to determine whether
+// the mouse is inside the window
+func (w *Window) MouseInsideWindow() bool { return w.window.MouseInsideWindow() }
+
+// Function to get the mouse's
+// position
+func (w *Window) MousePosition() pixel.Vec { return w.window.MousePosition() }
+
+// Function to get how much the
+// mouse wheel scrolled since the
+// last event
+func (w *Window) MouseScroll() pixel.Vec { return w.window.MouseScroll() }
+
+// Function to determine whether
+// the given mouse button is
+// currently pressed
+func (w *Window) MousePressed(b element.MouseButton) bool {
+	switch b {
+	case element.MouseButtonLeft:
+		return w.window.Pressed(pixelgl.MouseButtonLeft)
+	case element.MouseButtonRight:
+		return w.window.Pressed(pixelgl.MouseButtonRight)
+	case element.MouseButtonMiddle:
+		return w.window.Pressed(pixelgl.MouseButtonMiddle)
+	default:
+		return false
+	}
+}
+
+// The pixelgl button for each key
+var keys = map[element.Key]pixelgl.Button{
+	element.KeySpace:        pixelgl.KeySpace,
+	element.KeyEnter:        pixelgl.KeyEnter,
+	element.KeyEscape:       pixelgl.KeyEscape,
+	element.KeyTab:          pixelgl.KeyTab,
+	element.KeyBackspace:    pixelgl.KeyBackspace,
+	element.KeyInsert:       pixelgl.KeyInsert,
+	element.KeyDelete:       pixelgl.KeyDelete,
+	element.KeyRight:        pixelgl.KeyRight,
+	element.KeyLeft:         pixelgl.KeyLeft,
+	element.KeyDown:         pixelgl.KeyDown,
+	element.KeyUp:           pixelgl.KeyUp,
+	element.KeyPageUp:       pixelgl.KeyPageUp,
+	element.KeyPageDown:     pixelgl.KeyPageDown,
+	element.KeyHome:         pixelgl.KeyHome,
+	element.KeyEnd:          pixelgl.KeyEnd,
+	element.KeyLeftShift:    pixelgl.KeyLeftShift,
+	element.KeyRightShift:   pixelgl.KeyRightShift,
+	element.KeyLeftControl:  pixelgl.KeyLeftControl,
+	element.KeyRightControl: pixelgl.KeyRightControl,
+	element.KeyLeftAlt:      pixelgl.KeyLeftAlt,
+	element.KeyRightAlt:     pixelgl.KeyRightAlt,
+}
+
+func init() {
+	// Add the number and letter keys,
+	// which are in order for both
+	for i := 0; i < 10; i++ {
+		keys[element.Key0+element.Key(i)] = pixelgl.Key0 + pixelgl.Button(i)
+	}
+	for i := 0; i < 26; i++ {
+		keys[element.KeyA+element.Key(i)] = pixelgl.KeyA + pixelgl.Button(i)
+	}
+}
+
+// Function to determine whether
+// the given key is currently
+// pressed
+func (w *Window) KeyPressed(k element.Key) bool {
+	button, ok := keys[k]
+	return ok && w.window.Pressed(button)
+}
+
+// Function to get the text typed
+// since the last event
+func (w *Window) Typed() string { return w.window.Typed() }
+
+// Function to get the text atlas for
+// the given font face, creating it if
+// it doesn't exist yet
+func (w *Window) atlas(face font.Face) *text.Atlas {
+	atlas, ok := w.atlases[face]
+	if !ok {
+		atlas = text.NewAtlas(face, text.ASCII)
+		w.atlases[face] = atlas
+	}
+	return atlas
+}
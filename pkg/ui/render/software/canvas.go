@@ -0,0 +1,154 @@
+package software
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Type for an element.Surface
+// that draws onto an image
+type Canvas struct {
+	// The renderer that created
+	// the canvas
+	renderer *Renderer
+
+	// The canvas' bounds
+	bounds pixel.Rect
+
+	// The image itself
+	img *image.RGBA
+	// The part of the image that is
+	// drawn onto (the whole image,
+	// unless a clip is set)
+	dst *image.RGBA
+}
+
+// Function to get the canvas' image
+func (c *Canvas) Image() *image.RGBA { return c.img }
+
+// Function to get the canvas' bounds
+func (c *Canvas) Bounds() pixel.Rect { return c.bounds }
+
+// Function to set the canvas' bounds.
+// The image is only recreated if the
+// size changed
+func (c *Canvas) SetBounds(bounds pixel.Rect) {
+	if bounds.Size() != c.bounds.Size() {
+		c.img = newImage(bounds)
+	}
+	c.bounds = bounds
+	c.dst = c.img
+}
+
+// Function to restrict drawing
+// to the given rectangle of the
+// canvas (or nil for all of it)
+func (c *Canvas) SetClip(clip *pixel.Rect) {
+	if clip == nil {
+		c.dst = c.img
+		return
+	}
+	c.dst = c.img.SubImage(toImageRect(*clip, c.bounds)).(*image.RGBA)
+}
+
+// Function to clear the canvas
+// (or its clip) with the given colour
+func (c *Canvas) Clear(col color.Color) {
+	draw.Draw(c.dst, c.dst.Bounds(),
+		image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// Function to draw a sprite
+// onto the canvas
+func (c *Canvas) DrawSprite(s *pixel.Sprite, mat pixel.Matrix) {
+	// Get the picture as an image
+	src := c.renderer.image(s.Picture())
+
+	// Get the area of the image that
+	// the sprite's frame covers (the
+	// image's Y axis is flipped)
+	frame := s.Frame()
+	srcBounds := src.Bounds()
+	sr := image.Rect(
+		int(math.Floor(frame.Min.X)),
+		srcBounds.Min.Y+srcBounds.Max.Y-int(math.Ceil(frame.Max.Y)),
+		int(math.Ceil(frame.Max.X)),
+		srcBounds.Min.Y+srcBounds.Max.Y-int(math.Floor(frame.Min.Y)))
+
+	// The center of the frame on the image
+	anchor := pixel.V(float64(sr.Min.X)+frame.W()/2,
+		float64(sr.Min.Y)+frame.H()/2)
+
+	// Create a transformation from the image to
+	// the canvas, by converting to the sprite's
+	// space (centered on (0, 0)), applying the
+	// matrix then converting to the canvas' image
+	origin := toImage(mat.Project(pixel.ZV), c.bounds)
+	s2d := f64.Aff3{
+		mat[0], -mat[2], origin.X - mat[0]*anchor.X + mat[2]*anchor.Y,
+		-mat[1], mat[3], origin.Y + mat[1]*anchor.X - mat[3]*anchor.Y,
+	}
+
+	// If the matrix doesn't scale or rotate,
+	// don't bother interpolating
+	var interpolator draw.Interpolator = draw.ApproxBiLinear
+	if mat[0] == 1 && mat[1] == 0 && mat[2] == 0 && mat[3] == 1 {
+		interpolator = draw.NearestNeighbor
+	}
+
+	// Draw the image
+	interpolator.Transform(c.dst, s2d, src, sr, draw.Over, nil)
+}
+
+// Function to draw some text onto
+// the canvas. Only the matrix's
+// translation is used
+func (c *Canvas) DrawText(str string, face font.Face, col color.Color, mat pixel.Matrix) {
+	// Get the position of the first baseline
+	origin := toImage(mat.Project(pixel.ZV), c.bounds)
+	// Get the height of each line
+	lineHeight := float64(face.Metrics().Height) / 64
+
+	// Create the drawer
+	d := font.Drawer{
+		Dst:  c.dst,
+		Src:  image.NewUniform(col),
+		Face: face,
+	}
+
+	// Iterate over the lines
+	for i, line := range strings.Split(str, "\n") {
+		// Move the dot to the line's baseline
+		d.Dot = fixed.Point26_6{
+			X: fixed.Int26_6(origin.X * 64),
+			Y: fixed.Int26_6((origin.Y + lineHeight*float64(i)) * 64),
+		}
+		// Draw the line
+		d.DrawString(line)
+	}
+}
+
+// Function to draw another canvas
+// onto this canvas
+func (c *Canvas) DrawSurface(child element.Surface) {
+	child.(*Canvas).drawOnto(c.dst, c.bounds)
+}
+
+// Function to draw the canvas onto
+// an image with the given bounds, at
+// the canvas' bounds
+func (c *Canvas) drawOnto(dst *image.RGBA, bounds pixel.Rect) {
+	// Get where the top left of the canvas is on the image
+	min := toImage(pixel.V(c.bounds.Min.X, c.bounds.Max.Y), bounds)
+	pos := image.Pt(int(math.Round(min.X)), int(math.Round(min.Y)))
+	// Draw the canvas' image
+	draw.Draw(dst, c.img.Bounds().Add(pos), c.img, image.Point{}, draw.Over)
+}
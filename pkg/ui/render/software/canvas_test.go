@@ -0,0 +1,174 @@
+package software
+
+import (
+	"github.com/faiface/pixel"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// The colours of the test picture
+var (
+	red   = color.RGBA{R: 255, A: 255}
+	green = color.RGBA{G: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+// Function to create a 2x2 picture, which
+// is red and green along the top and blue
+// and white along the bottom
+func testPicture() *pixel.PictureData {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, red)
+	img.SetRGBA(1, 0, green)
+	img.SetRGBA(0, 1, blue)
+	img.SetRGBA(1, 1, white)
+	return pixel.PictureDataFromImage(img)
+}
+
+func TestCanvasSetClip(t *testing.T) {
+	r := NewRenderer(pixel.R(0, 0, 10, 10))
+	// The canvas isn't at the origin, so
+	// the clip has to be converted
+	c := r.NewSurface(pixel.R(10, 10, 20, 20)).(*Canvas)
+	c.Clear(red)
+
+	// Only the bottom left quarter is cleared
+	c.SetClip(&pixel.Rect{Min: pixel.V(10, 10), Max: pixel.V(15, 15)})
+	c.Clear(blue)
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{2, 7, blue},
+		{4, 5, blue},
+		{5, 5, red},
+		{4, 4, red},
+		{7, 7, red},
+		{2, 2, red},
+	} {
+		if got := c.Image().RGBAAt(p.x, p.y); got != p.want {
+			t.Errorf("pixel (%d, %d) after clearing the clip: got %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+
+	// Sprites are clipped too (this one
+	// covers the whole canvas)
+	c.DrawSprite(pixel.NewSprite(testPicture(), testPicture().Bounds()),
+		pixel.IM.Scaled(pixel.ZV, 5).Moved(pixel.V(15, 15)))
+	if got := c.Image().RGBAAt(9, 0); got != red {
+		t.Errorf("pixel (9, 0) outside the clip: got %v, want %v", got, red)
+	}
+	if got := c.Image().RGBAAt(0, 9); got != blue {
+		t.Errorf("pixel (0, 9) inside the clip: got %v, want %v (the sprite's bottom left)", got, blue)
+	}
+
+	// Removing the clip (or setting the
+	// bounds) lets all of it be drawn onto
+	for _, reset := range []struct {
+		name string
+		f    func()
+	}{
+		{"SetClip(nil)", func() { c.SetClip(nil) }},
+		{"SetBounds", func() { c.SetBounds(pixel.R(30, 30, 40, 40)) }},
+	} {
+		c.SetClip(&pixel.Rect{Min: pixel.V(10, 10), Max: pixel.V(15, 15)})
+		reset.f()
+		c.Clear(green)
+		bounds := c.Image().Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if got := c.Image().RGBAAt(x, y); got != green {
+					t.Fatalf("pixel (%d, %d) after %s: got %v, want %v", x, y, reset.name, got, green)
+				}
+			}
+		}
+	}
+}
+
+func TestCanvasDrawSprite(t *testing.T) {
+	transparent := color.RGBA{}
+	// The pixels to check, and their colours
+	type want struct {
+		x, y int
+		col  color.RGBA
+	}
+	tests := []struct {
+		name string
+		mat  pixel.Matrix
+		want []want
+	}{
+		{
+			name: "translated",
+			mat:  pixel.IM.Moved(pixel.V(5, 5)),
+			want: []want{
+				{4, 4, red}, {5, 4, green}, {4, 5, blue}, {5, 5, white},
+				{3, 4, transparent}, {6, 5, transparent}, {4, 3, transparent}, {5, 6, transparent},
+			},
+		},
+		{
+			name: "scaled",
+			mat:  pixel.IM.Scaled(pixel.ZV, 2).Moved(pixel.V(5, 5)),
+			want: []want{
+				{3, 3, red}, {6, 3, green}, {3, 6, blue}, {6, 6, white},
+				{2, 3, transparent}, {7, 6, transparent}, {3, 2, transparent}, {6, 7, transparent},
+			},
+		},
+		{
+			name: "flipped",
+			mat:  pixel.IM.ScaledXY(pixel.ZV, pixel.V(-1, 1)).Moved(pixel.V(5, 5)),
+			want: []want{{4, 4, green}, {5, 4, red}, {4, 5, white}, {5, 5, blue}},
+		},
+		{
+			// Pixel's Y axis points up, so a positive
+			// angle rotates anticlockwise
+			name: "rotated",
+			mat:  pixel.IM.Rotated(pixel.ZV, math.Pi/2).Moved(pixel.V(5, 5)),
+			want: []want{{4, 4, green}, {5, 4, white}, {4, 5, red}, {5, 5, blue}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRenderer(pixel.R(0, 0, 10, 10))
+			c := r.NewSurface(pixel.R(0, 0, 10, 10)).(*Canvas)
+			pic := testPicture()
+			c.DrawSprite(pixel.NewSprite(pic, pic.Bounds()), test.mat)
+			for _, w := range test.want {
+				if got := c.Image().RGBAAt(w.x, w.y); got != w.col {
+					t.Errorf("pixel (%d, %d): got %v, want %v", w.x, w.y, got, w.col)
+				}
+			}
+		})
+	}
+}
+
+func TestRendererReset(t *testing.T) {
+	r := NewRenderer(pixel.R(0, 0, 10, 10))
+	c := r.NewSurface(pixel.R(0, 0, 10, 10))
+	pic := testPicture()
+	sprite := pixel.NewSprite(pic, pic.Bounds())
+
+	// The picture is converted once
+	c.DrawSprite(sprite, pixel.IM.Moved(pixel.V(5, 5)))
+	img, ok := r.images[pic]
+	if !ok || len(r.images) != 1 {
+		t.Fatalf("got %d cached images, want the picture's", len(r.images))
+	}
+	c.DrawSprite(sprite, pixel.IM.Moved(pixel.V(2, 2)))
+	if len(r.images) != 1 || r.images[pic] != img {
+		t.Errorf("drawing the picture again didn't use the cached image")
+	}
+
+	// Resetting forgets it
+	r.Reset()
+	if len(r.images) != 0 {
+		t.Errorf("got %d cached images after Reset, want none", len(r.images))
+	}
+	// So it's converted again
+	c.DrawSprite(sprite, pixel.IM.Moved(pixel.V(5, 5)))
+	if _, ok := r.images[pic]; !ok || len(r.images) != 1 {
+		t.Errorf("the picture wasn't converted again after Reset")
+	}
+}
@@ -0,0 +1,147 @@
+package software
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/draw"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Type for an element.Renderer that
+// rasterises the element tree into
+// an image, without needing a GPU
+// or a display
+type Renderer struct {
+	// The renderer's bounds
+	bounds pixel.Rect
+
+	// The image the UI was last
+	// presented to
+	output *image.RGBA
+
+	// Cache of the images converted
+	// from pixel pictures, with the key
+	// being the picture (see Reset)
+	images map[pixel.Picture]*image.RGBA
+}
+
+// Function to create a renderer
+// with the given bounds
+func NewRenderer(bounds pixel.Rect) *Renderer {
+	r := new(Renderer)
+	r.Reset()
+	r.SetBounds(bounds)
+	return r
+}
+
+// Function to get the renderer's bounds
+func (r *Renderer) Bounds() pixel.Rect { return r.bounds }
+
+// Function to set the renderer's
+// bounds. The output image is cleared
+func (r *Renderer) SetBounds(bounds pixel.Rect) {
+	r.bounds = bounds
+	r.output = newImage(bounds)
+}
+
+// Function to forget the images converted
+// from pixel pictures, so the pictures can
+// be freed. It should be called when the
+// pictures stop being drawn (e.g. when a
+// different element tree is drawn)
+func (r *Renderer) Reset() {
+	r.images = make(map[pixel.Picture]*image.RGBA)
+}
+
+// Function to get the image the
+// UI was last presented to
+func (r *Renderer) Image() *image.RGBA { return r.output }
+
+// Function to create a new canvas
+// with the given bounds
+func (r *Renderer) NewSurface(bounds pixel.Rect) element.Surface {
+	img := newImage(bounds)
+	return &Canvas{renderer: r, bounds: bounds, img: img, dst: img}
+}
+
+// Function to draw the damaged regions
+// of the given canvas onto the output
+// image (or all of it, if damage is nil)
+func (r *Renderer) Present(s element.Surface, damage []pixel.Rect) {
+	if damage == nil {
+		damage = []pixel.Rect{r.bounds}
+	}
+	for _, region := range damage {
+		// Only draw onto the region
+		dst := r.output.SubImage(toImageRect(region, r.bounds)).(*image.RGBA)
+		// Clear it
+		draw.Draw(dst, dst.Bounds(),
+			image.NewUniform(color.Transparent), image.Point{}, draw.Src)
+		// Draw the canvas onto it
+		s.(*Canvas).drawOnto(dst, r.bounds)
+	}
+}
+
+// Function to get a pixel picture
+// as an image
+func (r *Renderer) image(pic pixel.Picture) *image.RGBA {
+	img, ok := r.images[pic]
+	if !ok {
+		// Convert the picture to picture data
+		pd, ok := pic.(*pixel.PictureData)
+		if !ok {
+			pd = pixel.PictureDataFromPicture(pic)
+		}
+		// Convert the picture data to an image
+		img = pd.Image()
+		r.images[pic] = img
+	}
+	return img
+}
+
+// Function to create an image that
+// is the size of the given bounds
+func newImage(bounds pixel.Rect) *image.RGBA {
+	return image.NewRGBA(image.Rect(0, 0,
+		int(math.Ceil(bounds.W())), int(math.Ceil(bounds.H()))))
+}
+
+// Function to convert a point in
+// "pixel" space to a point on an
+// image with the given bounds.
+// Pixel's Y axis points up, where
+// an image's points down
+func toImage(v pixel.Vec, bounds pixel.Rect) pixel.Vec {
+	return pixel.V(v.X-bounds.Min.X, bounds.Max.Y-v.Y)
+}
+
+// Function to convert a rectangle in
+// "pixel" space to the pixels it covers
+// on an image with the given bounds
+func toImageRect(rect pixel.Rect, bounds pixel.Rect) image.Rectangle {
+	min := toImage(pixel.V(rect.Min.X, rect.Max.Y), bounds)
+	max := toImage(pixel.V(rect.Max.X, rect.Min.Y), bounds)
+	return image.Rect(
+		int(math.Floor(min.X)), int(math.Floor(min.Y)),
+		int(math.Ceil(max.X)), int(math.Ceil(max.Y)))
+}
+
+// Function to render an element tree
+// into an image with the given bounds
+func Render(e element.Element, bounds pixel.Rect) (*image.RGBA, error) {
+	// Create the renderer
+	r := NewRenderer(bounds)
+
+	// Initialise the UI
+	err := element.InitUI(e, r, &bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	// Draw the UI
+	element.DrawUI(e, r)
+
+	return r.Image(), nil
+}
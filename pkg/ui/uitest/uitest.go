@@ -0,0 +1,197 @@
+package uitest
+
+import (
+	_ "github.com/bhollier/ui/pkg/ui/builtin"
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/render/software"
+	"github.com/faiface/pixel"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The environment variable that, when set
+// to a non-empty value, makes AssertGolden
+// (re)write the golden images instead of
+// comparing against them
+const UpdateEnvVar = "UITEST_UPDATE"
+
+// Type for the options of a
+// snapshot comparison
+type Options struct {
+	// The size of the window the design
+	// is laid out and rendered in
+	WindowSize pixel.Vec
+
+	// The maximum difference allowed
+	// between each colour channel of
+	// a pixel before it counts as
+	// a different pixel
+	Tolerance uint8
+
+	// The number of pixels allowed to
+	// be different before the comparison
+	// fails
+	MaxDiffPixels int
+}
+
+// The default options
+var DefaultOptions = Options{
+	WindowSize:    pixel.V(800, 600),
+	Tolerance:     0,
+	MaxDiffPixels: 0,
+}
+
+// Function to load a design from the
+// given filesystem, lay it out in a
+// window of the given size and render
+// it headlessly
+func Render(fs http.FileSystem, path string, size pixel.Vec) (*image.RGBA, error) {
+	// Load the design
+	root, err := element.NewRoot(fs, nil, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Render it
+	return software.Render(root.Element, pixel.R(0, 0, size.X, size.Y))
+}
+
+// Function to compare two images, with
+// the given per-channel tolerance.
+// Returns the number of pixels that
+// differ and an image highlighting
+// them in red over a faded copy of
+// want
+func Compare(got, want image.Image, tolerance uint8) (int, *image.RGBA) {
+	// Get the union of the image's bounds
+	bounds := got.Bounds().Union(want.Bounds())
+	diff := image.NewRGBA(bounds)
+
+	// Function to get the difference
+	// between two colour channels
+	channelDiff := func(a, b uint32) uint32 {
+		if a > b {
+			return (a - b) >> 8
+		}
+		return (b - a) >> 8
+	}
+
+	// Iterate over the pixels
+	n := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			// If the pixel is outside either image
+			if !p.In(got.Bounds()) || !p.In(want.Bounds()) {
+				n++
+				diff.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+				continue
+			}
+
+			// Get the colours
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+
+			// If any of the channels are too different
+			t := uint32(tolerance)
+			if channelDiff(r1, r2) > t || channelDiff(g1, g2) > t ||
+				channelDiff(b1, b2) > t || channelDiff(a1, a2) > t {
+				n++
+				diff.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				// Otherwise fade the wanted colour (as
+				// the colour is premultiplied, the grey
+				// is faded as much as the alpha)
+				grey := uint8(((r2+g2+b2)/3)>>8) / 4
+				diff.SetRGBA(x, y, color.RGBA{
+					R: grey, G: grey, B: grey, A: uint8(a2>>8) / 4})
+			}
+		}
+	}
+	return n, diff
+}
+
+// Function to load a PNG image
+func LoadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+// Function to save an image as a PNG,
+// creating its directory if needed
+func SavePNG(path string, img image.Image) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// Function to render the design at path
+// (in the given filesystem) and compare
+// it against the golden PNG at golden
+// (on disk). On failure, the rendered
+// image and a diff image are written
+// next to the golden image. If the
+// UITEST_UPDATE environment variable
+// is set, the golden image is written
+// instead
+func AssertGolden(t testing.TB, fs http.FileSystem, path string,
+	golden string, opts Options) {
+	t.Helper()
+
+	// Render the design
+	got, err := Render(fs, path, opts.WindowSize)
+	if err != nil {
+		t.Fatalf("error rendering design '%s': %+v", path, err)
+	}
+
+	// If the golden image should be updated
+	if os.Getenv(UpdateEnvVar) != "" {
+		err = SavePNG(golden, got)
+		if err != nil {
+			t.Fatalf("error writing golden image '%s': %+v", golden, err)
+		}
+		return
+	}
+
+	// Load the golden image
+	want, err := LoadPNG(golden)
+	if err != nil {
+		t.Fatalf("error loading golden image '%s' (set %s=1 to create it): %+v",
+			golden, UpdateEnvVar, err)
+	}
+
+	// Compare the images
+	n, diff := Compare(got, want, opts.Tolerance)
+	if n > opts.MaxDiffPixels {
+		// Write the rendered and diff images
+		base := strings.TrimSuffix(golden, filepath.Ext(golden))
+		gotPath, diffPath := base+".got.png", base+".diff.png"
+		err = SavePNG(gotPath, got)
+		if err != nil {
+			t.Errorf("error writing rendered image '%s': %+v", gotPath, err)
+		}
+		err = SavePNG(diffPath, diff)
+		if err != nil {
+			t.Errorf("error writing diff image '%s': %+v", diffPath, err)
+		}
+		t.Errorf("design '%s' differs from golden image '%s' by %d pixel(s) "+
+			"(see '%s' and '%s')", path, golden, n, gotPath, diffPath)
+	}
+}
@@ -0,0 +1,218 @@
+package uitest
+
+import (
+	"github.com/bhollier/ui/pkg/ui/element"
+	"github.com/bhollier/ui/pkg/ui/render/software"
+	"github.com/faiface/pixel"
+	"image"
+	"image/color"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Function to create an image of the
+// given size filled with the given colour
+func filledImage(w, h int, col color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, col)
+		}
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	red := color.RGBA{R: 200, A: 255}
+	nearlyRed := color.RGBA{R: 203, G: 2, A: 255}
+
+	// An image with one pixel changed
+	changed := filledImage(4, 4, red)
+	changed.SetRGBA(1, 2, color.RGBA{B: 255, A: 255})
+
+	tests := []struct {
+		name      string
+		got, want image.Image
+		tolerance uint8
+		diff      int
+	}{
+		{"identical", filledImage(4, 4, red), filledImage(4, 4, red), 0, 0},
+		{"within tolerance", filledImage(4, 4, nearlyRed), filledImage(4, 4, red), 3, 0},
+		{"outside tolerance", filledImage(4, 4, nearlyRed), filledImage(4, 4, red), 2, 16},
+		{"one pixel", changed, filledImage(4, 4, red), 10, 1},
+		// The pixels outside either image differ
+		{"size mismatch", filledImage(4, 4, red), filledImage(4, 2, red), 0, 8},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n, diff := Compare(test.got, test.want, test.tolerance)
+			if n != test.diff {
+				t.Errorf("got %d different pixels, want %d", n, test.diff)
+			}
+			bounds := test.got.Bounds().Union(test.want.Bounds())
+			if diff.Bounds() != bounds {
+				t.Errorf("got diff bounds %v, want %v", diff.Bounds(), bounds)
+			}
+		})
+	}
+}
+
+func TestCompareDiffImage(t *testing.T) {
+	want := filledImage(2, 1, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	got := filledImage(2, 1, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	got.SetRGBA(1, 0, color.RGBA{A: 255})
+
+	_, diff := Compare(got, want, 0)
+	// The matching pixel is faded, and must still
+	// be a valid premultiplied colour
+	faded := diff.RGBAAt(0, 0)
+	if faded.A == 0 || faded.R > faded.A || faded.G > faded.A || faded.B > faded.A {
+		t.Errorf("got faded pixel %v, want a valid premultiplied grey", faded)
+	}
+	// The different pixel is red
+	if c := diff.RGBAAt(1, 0); c != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("got different pixel %v, want red", c)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	fs := http.Dir("testdata")
+	opts := Options{WindowSize: pixel.V(120, 90)}
+	for _, name := range []string{"linearlayout", "gridlayout", "relativelayout"} {
+		t.Run(name, func(t *testing.T) {
+			AssertGolden(t, fs, "designs/"+name+".xml",
+				filepath.Join("testdata", "golden", name+".png"), opts)
+		})
+	}
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	fs := http.Dir("testdata")
+	opts := Options{WindowSize: pixel.V(40, 30)}
+	golden := filepath.Join(t.TempDir(), "golden", "linearlayout.png")
+
+	// Updating writes the golden image
+	t.Setenv(UpdateEnvVar, "1")
+	AssertGolden(t, fs, "designs/linearlayout.xml", golden, opts)
+	_, err := os.Stat(golden)
+	if err != nil {
+		t.Fatalf("golden image wasn't written: %v", err)
+	}
+
+	// Which is then matched
+	t.Setenv(UpdateEnvVar, "")
+	AssertGolden(t, fs, "designs/linearlayout.xml", golden, opts)
+}
+
+// Type for a renderer that records
+// the damage it's asked to present
+type damageRecorder struct {
+	*software.Renderer
+	damage [][]pixel.Rect
+}
+
+func (r *damageRecorder) Present(s element.Surface, damage []pixel.Rect) {
+	r.damage = append(r.damage, damage)
+	r.Renderer.Present(s, damage)
+}
+
+// Function to convert a rectangle to the
+// pixels it covers on an image of the
+// given bounds (the image's Y axis is
+// flipped)
+func imageRect(r pixel.Rect, bounds pixel.Rect) image.Rectangle {
+	return image.Rect(int(r.Min.X-bounds.Min.X), int(bounds.Max.Y-r.Max.Y),
+		int(r.Max.X-bounds.Min.X), int(bounds.Max.Y-r.Min.Y))
+}
+
+func TestPartialRedraw(t *testing.T) {
+	bounds := pixel.R(0, 0, 120, 90)
+	root, err := element.NewRoot(http.Dir("testdata"), nil, "designs/damage.xml", nil)
+	if err != nil {
+		t.Fatalf("error loading design: %v", err)
+	}
+	r := &damageRecorder{Renderer: software.NewRenderer(bounds)}
+	layoutBounds := bounds
+	err = element.InitUI(root.Element, r, &layoutBounds)
+	if err != nil {
+		t.Fatalf("error initialising design: %v", err)
+	}
+	element.DrawUI(root.Element, r)
+	before := image.NewRGBA(r.Image().Bounds())
+	copy(before.Pix, r.Image().Pix)
+
+	// Disabling the button changes its
+	// background, so only it is damaged
+	button := root.Element.(element.Layout).GetChild(1).(element.Button)
+	button.SetEnabled(false)
+	damage := root.Element.GetDamage()
+	if len(damage) != 1 || damage[0] != *button.GetBounds() {
+		t.Fatalf("got damage %v, want the button's bounds %v", damage, *button.GetBounds())
+	}
+	damaged := imageRect(damage[0], bounds)
+
+	// Mark the root's canvas outside the
+	// damage, which shows if the layout or
+	// the renderer draw anything there
+	marker := color.RGBA{R: 255, B: 255, A: 255}
+	canvas := root.Element.GetCanvas().(*software.Canvas).Image()
+	for y := 0; y < canvas.Bounds().Dy(); y++ {
+		for x := 0; x < canvas.Bounds().Dx(); x++ {
+			if !image.Pt(x, y).In(damaged) {
+				canvas.SetRGBA(x, y, marker)
+			}
+		}
+	}
+
+	r.damage = nil
+	element.DrawUI(root.Element, r)
+	if len(r.damage) != 1 || len(r.damage[0]) != 1 || r.damage[0][0] != damage[0] {
+		t.Errorf("got presented damage %v, want [%v]", r.damage, damage)
+	}
+	black := color.RGBA{A: 255}
+	for y := 0; y < canvas.Bounds().Dy(); y++ {
+		for x := 0; x < canvas.Bounds().Dx(); x++ {
+			inside := image.Pt(x, y).In(damaged)
+			// The layout only draws the damage
+			if got := canvas.RGBAAt(x, y); !inside && got != marker {
+				t.Fatalf("root canvas pixel (%d, %d) outside the damage was drawn (%v)", x, y, got)
+			} else if inside && got != black {
+				t.Fatalf("root canvas pixel (%d, %d) inside the damage: got %v, want %v", x, y, got, black)
+			}
+			// And the renderer only presents it
+			if got, want := r.Image().RGBAAt(x, y), before.RGBAAt(x, y); !inside && got != want {
+				t.Fatalf("output pixel (%d, %d) outside the damage: got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// The result is the same as
+	// redrawing everything
+	partial := r.Image()
+	full := software.NewRenderer(bounds)
+	root.Element.InvalidatePaint()
+	element.DrawUI(root.Element, full)
+	if n, _ := Compare(partial, full.Image(), 0); n != 0 {
+		t.Errorf("partial redraw differs from a full redraw by %d pixel(s)", n)
+	}
+
+	// Which matches the golden image
+	golden := filepath.Join("testdata", "golden", "damage.png")
+	if os.Getenv(UpdateEnvVar) != "" {
+		err = SavePNG(golden, partial)
+		if err != nil {
+			t.Fatalf("error writing golden image '%s': %+v", golden, err)
+		}
+		return
+	}
+	want, err := LoadPNG(golden)
+	if err != nil {
+		t.Fatalf("error loading golden image '%s' (set %s=1 to create it): %+v",
+			golden, UpdateEnvVar, err)
+	}
+	if n, _ := Compare(partial, want, 0); n != 0 {
+		t.Errorf("partial redraw differs from golden image '%s' by %d pixel(s)", golden, n)
+	}
+}
@@ -17,15 +17,14 @@ func ParseColor(str string) (color.RGBA, error) {
 	if len(str) > 8 {
 		return color.RGBA{}, errors.New("invalid colour format")
 
-		// If the string is way too short
-	} else if len(str) < 6 {
+		// If the string is too short, or has
+		// half of the alpha
+	} else if len(str) != 6 && len(str) != 8 {
 		return color.RGBA{}, errors.New("invalid colour format")
 
 		// If the string is missing the alpha
-	} else {
-		for len(str) < 8 {
-			str = str + "F"
-		}
+	} else if len(str) == 6 {
+		str = str + "FF"
 	}
 
 	// Decode the string with hex
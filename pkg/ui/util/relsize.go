@@ -159,3 +159,24 @@ func ParseRelativeSize(str string) (size RelativeSize, err error) {
 	}
 	return RelativeSize{RelativeQuantity: quantity}, nil
 }
+
+// Function to convert the relative
+// quantity to a string
+func (q RelativeQuantity) String() string {
+	return strconv.Itoa(int(q.Quantity)) + string(q.Unit)
+}
+
+// Function to convert the relative
+// size to a string
+func (s RelativeSize) String() string {
+	switch {
+	case s.MatchParent:
+		return "match_parent"
+	case s.MatchContent:
+		return "match_content"
+	case s.MatchBounds:
+		return "match_bounds"
+	default:
+		return s.RelativeQuantity.String()
+	}
+}
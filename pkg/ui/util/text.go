@@ -0,0 +1,41 @@
+package util
+
+import (
+	"github.com/faiface/pixel"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"math"
+	"strings"
+)
+
+// Function to convert a fixed point
+// number to a float
+func fixedToFloat(i fixed.Int26_6) float64 {
+	return float64(i) / 64
+}
+
+// Function to calculate the bounds of
+// some text drawn with the given face.
+// The baseline of the first line starts
+// at (0, 0), and each line after is
+// drawn below the previous one
+func TextBounds(face font.Face, str string) pixel.Rect {
+	// Get the face's metrics
+	metrics := face.Metrics()
+	ascent := fixedToFloat(metrics.Ascent)
+	descent := fixedToFloat(metrics.Descent)
+	lineHeight := fixedToFloat(metrics.Height)
+
+	// Split the text into lines
+	lines := strings.Split(str, "\n")
+
+	// Get the width of the widest line
+	var width float64
+	for _, line := range lines {
+		width = math.Max(width,
+			fixedToFloat(font.MeasureString(face, line)))
+	}
+
+	return pixel.R(0, -descent-lineHeight*float64(len(lines)-1),
+		width, ascent)
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How often the design's
+// files are checked for changes
+const watchInterval = 250 * time.Millisecond
+
+// How long the design's files have
+// to stay the same after a change
+// before the design is reloaded
+const watchDebounce = 300 * time.Millisecond
+
+// Type for a file system that
+// records the paths of the files
+// opened through it
+type recordingFS struct {
+	http.FileSystem
+	sync.Mutex
+
+	// The paths of the files
+	// opened (which may not exist)
+	paths map[string]bool
+}
+
+// Function to create a file system
+// that records the paths of the files
+// opened through the given one
+func newRecordingFS(fs http.FileSystem) *recordingFS {
+	return &recordingFS{FileSystem: fs, paths: make(map[string]bool)}
+}
+
+// Function to open a file,
+// recording its path
+func (fs *recordingFS) Open(name string) (http.File, error) {
+	fs.Lock()
+	fs.paths[path.Clean("/"+name)] = true
+	fs.Unlock()
+	return fs.FileSystem.Open(name)
+}
+
+// Function to get the paths
+// of the files opened
+func (fs *recordingFS) files() []string {
+	fs.Lock()
+	defer fs.Unlock()
+	paths := make([]string, 0, len(fs.paths))
+	for p := range fs.paths {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// Function to forget the paths
+// of the files opened, returning
+// them (see restore)
+func (fs *recordingFS) reset() map[string]bool {
+	fs.Lock()
+	defer fs.Unlock()
+	paths := fs.paths
+	fs.paths = make(map[string]bool)
+	return paths
+}
+
+// Function to add paths (from
+// reset) back to the files opened
+func (fs *recordingFS) restore(paths map[string]bool) {
+	fs.Lock()
+	defer fs.Unlock()
+	for p := range paths {
+		fs.paths[p] = true
+	}
+}
+
+// Type for what a watched file
+// was like when it was checked
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// Function to get what the
+// file at the given path is like
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{true, info.ModTime(), info.Size()}
+}
+
+// Function to reload the design when
+// any file it uses (its XML, the designs
+// it imports, images, fonts etc.) changes.
+// dir is the directory on disk that the
+// design's file system reads from (e.g. the
+// one given to http.Dir). If the design can't
+// be reloaded, the old elements are kept and
+// the error is shown over them (and sent to
+// Errors) until it's fixed. The watching stops
+// when the design closes
+func (d *Design) Watch(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New("'" + dir + "' isn't a directory")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	if d.watching {
+		return errors.New("the design is already being watched")
+	}
+	d.watching = true
+	go d.watch(dir)
+	return nil
+}
+
+// Function to check the design's files
+// for changes (after they've stayed the
+// same for a while) until it's closed
+func (d *Design) watch(dir string) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	// What the files were like
+	states := make(map[string]fileState)
+	// Whether the files have changed since
+	// the design was reloaded, and when
+	// they last changed
+	changed := false
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+		}
+
+		// Check every file the design has opened
+		for _, p := range d.files.files() {
+			state := statFile(filepath.Join(dir, filepath.FromSlash(p)))
+			prev, ok := states[p]
+			states[p] = state
+			// New files are only compared
+			// from the next check
+			if ok && prev != state {
+				changed = true
+				lastChange = time.Now()
+			}
+		}
+
+		// If they've stopped changing, reload
+		if changed && time.Since(lastChange) >= watchDebounce {
+			changed = false
+			d.Reload()
+		}
+	}
+}
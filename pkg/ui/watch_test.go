@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingFS(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "design.xml"), []byte("<Image/>"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := newRecordingFS(http.Dir(dir))
+
+	// Function to get the recorded paths, in order
+	files := func() string {
+		paths := fs.files()
+		sort.Strings(paths)
+		return strings.Join(paths, " ")
+	}
+
+	// Files are recorded even if they don't exist
+	for _, name := range []string{"design.xml", "/images/../design.xml", "missing.png"} {
+		file, err := fs.Open(name)
+		if err == nil {
+			file.Close()
+		}
+	}
+	if got, want := files(), "/design.xml /missing.png"; got != want {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+
+	// Resetting forgets them until they're restored
+	prev := fs.reset()
+	if got := files(); got != "" {
+		t.Errorf("got files %q after reset, want none", got)
+	}
+	fs.Open("other.xml")
+	fs.restore(prev)
+	if got, want := files(), "/design.xml /missing.png /other.xml"; got != want {
+		t.Errorf("got files %q after restore, want %q", got, want)
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "design.xml")
+	err := ioutil.WriteFile(file, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{"missing directory", filepath.Join(dir, "missing")},
+		{"not a directory", file},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newTestDesign()
+			if err := d.Watch(test.dir); err == nil {
+				t.Errorf("got no error watching '%s'", test.dir)
+			}
+		})
+	}
+
+	t.Run("already watching", func(t *testing.T) {
+		d := newTestDesign()
+		d.files = newRecordingFS(http.Dir(dir))
+		defer close(d.quit)
+		if err := d.Watch(dir); err != nil {
+			t.Fatalf("error watching '%s': %v", dir, err)
+		}
+		if err := d.Watch(dir); err == nil {
+			t.Errorf("got no error watching the design twice")
+		}
+	})
+}
+
+func TestWatchReloads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "design.xml")
+	err := ioutil.WriteFile(path, []byte("<Image/>"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An unrelated file in the directory
+	other := filepath.Join(dir, "other.xml")
+	err = ioutil.WriteFile(other, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newTestDesign()
+	d.files = newRecordingFS(http.Dir(dir))
+	file, err := d.files.Open("design.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	stopped := make(chan struct{})
+	go func() {
+		d.watch(dir)
+		close(stopped)
+	}()
+	// Function to get the number of
+	// reloads that have been posted
+	reloads := func() int {
+		d.postedLock.Lock()
+		defer d.postedLock.Unlock()
+		return len(d.posted)
+	}
+
+	// Wait for the files to be checked, then
+	// change the unrelated file (which isn't
+	// reloaded for) and the design several
+	// times (which is only reloaded for once)
+	time.Sleep(2 * watchInterval)
+	ioutil.WriteFile(other, []byte("changed"), 0644)
+	for i := 1; i <= 3; i++ {
+		ioutil.WriteFile(path, []byte("<Image/>"+strings.Repeat(" ", i)), 0644)
+		time.Sleep(watchInterval / 2)
+	}
+
+	deadline := time.Now().Add(watchInterval + watchDebounce + time.Second)
+	for reloads() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Check nothing else is posted
+	time.Sleep(2 * watchInterval)
+	if n := reloads(); n != 1 {
+		t.Errorf("got %d reloads, want 1", n)
+	}
+
+	// It stops when the design closes
+	close(d.quit)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Errorf("the watcher didn't stop when the design closed")
+	}
+}